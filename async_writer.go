@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+type OverflowPolicy uint8
+
+const (
+	OverflowBlock OverflowPolicy = iota
+	OverflowDropNewest
+	OverflowDropOldest
+)
+
+const defaultAsyncQueueSize = 1024
+
+type AsyncWriter struct {
+	ErrorHandler func(error)
+
+	out io.Writer
+
+	size int
+
+	policy OverflowPolicy
+
+	mu sync.Mutex
+
+	cond *sync.Cond
+
+	queue [][]byte
+
+	writing bool
+
+	closed bool
+
+	dropped uint64
+
+	done chan struct{}
+}
+
+type flusher interface {
+	Flush() error
+}
+
+func NewAsyncWriter(out io.Writer, size int, policy OverflowPolicy) *AsyncWriter {
+	if size <= 0 {
+		size = defaultAsyncQueueSize
+	}
+	w := &AsyncWriter{
+		out:    out,
+		size:   size,
+		policy: policy,
+		queue:  make([][]byte, 0, size),
+		done:   make(chan struct{}),
+	}
+	w.cond = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+// Write queues a copy of p, since the formatters hand over pooled buffers
+// that are reused as soon as Write returns.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for !w.closed && len(w.queue) >= w.size {
+		switch w.policy {
+		case OverflowDropNewest:
+			atomic.AddUint64(&w.dropped, 1)
+			return len(p), nil
+		case OverflowDropOldest:
+			w.queue[0] = nil
+			w.queue = w.queue[1:]
+			atomic.AddUint64(&w.dropped, 1)
+		default:
+			w.cond.Wait()
+		}
+	}
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+
+	w.queue = append(w.queue, append([]byte(nil), p...))
+	w.cond.Broadcast()
+	return len(p), nil
+}
+
+func (w *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+func (w *AsyncWriter) Flush() error {
+	w.mu.Lock()
+	for len(w.queue) > 0 || w.writing {
+		w.cond.Wait()
+	}
+	w.mu.Unlock()
+
+	if f, ok := w.out.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+
+	<-w.done
+	if f, ok := w.out.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+
+	w.mu.Lock()
+	for {
+		for len(w.queue) == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if len(w.queue) == 0 {
+			w.mu.Unlock()
+			return
+		}
+		batch := w.queue
+		w.queue = make([][]byte, 0, w.size)
+		w.writing = true
+		w.cond.Broadcast()
+		w.mu.Unlock()
+
+		for _, b := range batch {
+			if _, err := w.out.Write(b); err != nil {
+				w.reportError(err)
+			}
+		}
+
+		w.mu.Lock()
+		w.writing = false
+		w.cond.Broadcast()
+	}
+}
+
+func (w *AsyncWriter) reportError(err error) {
+	if w.ErrorHandler != nil {
+		w.ErrorHandler(err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+}
@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gateWriter holds up its first Write until release is closed, so a test can
+// fill the AsyncWriter's queue behind it.
+type gateWriter struct {
+	started chan struct{}
+
+	release chan struct{}
+
+	once sync.Once
+
+	mu sync.Mutex
+
+	lines []string
+
+	flushes int
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (g *gateWriter) Write(p []byte) (int, error) {
+	g.once.Do(func() {
+		close(g.started)
+		<-g.release
+	})
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.lines = append(g.lines, string(p))
+	return len(p), nil
+}
+
+func (g *gateWriter) Flush() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.flushes++
+	return nil
+}
+
+func (g *gateWriter) written() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]string(nil), g.lines...)
+}
+
+func TestAsyncWriterOverflowDrop(t *testing.T) {
+	tests := []struct {
+		policy OverflowPolicy
+		want   []string
+	}{
+		{OverflowDropNewest, []string{"a", "b", "c"}},
+		{OverflowDropOldest, []string{"a", "c", "d"}},
+	}
+	for _, tt := range tests {
+		out := newGateWriter()
+		w := NewAsyncWriter(out, 2, tt.policy)
+		w.Write([]byte("a"))
+		<-out.started
+		for _, s := range []string{"b", "c", "d"} {
+			if n, err := w.Write([]byte(s)); n != 1 || err != nil {
+				t.Fatalf("policy %d: Write(%q) = %d, %v", tt.policy, s, n, err)
+			}
+		}
+		close(out.release)
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if got := out.written(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("policy %d: wrote %v, want %v", tt.policy, got, tt.want)
+		}
+		if w.Dropped() != 1 {
+			t.Errorf("policy %d: Dropped() = %d, want 1", tt.policy, w.Dropped())
+		}
+		w.Close()
+	}
+}
+
+func TestAsyncWriterOverflowBlock(t *testing.T) {
+	out := newGateWriter()
+	w := NewAsyncWriter(out, 1, OverflowBlock)
+	defer w.Close()
+	w.Write([]byte("a"))
+	<-out.started
+	w.Write([]byte("b"))
+
+	returned := make(chan struct{})
+	go func() {
+		w.Write([]byte("c"))
+		close(returned)
+	}()
+	select {
+	case <-returned:
+		t.Fatal("Write returned while the queue was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(out.release)
+	<-returned
+
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.written(), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrote %v, want %v", got, want)
+	}
+	if w.Dropped() != 0 {
+		t.Errorf("Dropped() = %d, want 0", w.Dropped())
+	}
+}
+
+func TestAsyncWriterFlush(t *testing.T) {
+	out := newGateWriter()
+	close(out.release)
+	w := NewAsyncWriter(out, 0, OverflowBlock)
+	defer w.Close()
+
+	buf := []byte("one")
+	w.Write(buf)
+	// The formatters reuse their buffers once Write returns.
+	copy(buf, "xxx")
+	w.Write([]byte("two"))
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.written(), []string{"one", "two"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrote %v, want %v", got, want)
+	}
+	out.mu.Lock()
+	flushes := out.flushes
+	out.mu.Unlock()
+	if flushes != 1 {
+		t.Errorf("Flush reached the writer %d times, want 1", flushes)
+	}
+}
+
+func TestAsyncWriterClose(t *testing.T) {
+	out := newGateWriter()
+	close(out.release)
+	w := NewAsyncWriter(out, 0, OverflowBlock)
+	for _, s := range []string{"a", "b", "c"} {
+		w.Write([]byte(s))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.written(), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrote %v, want %v", got, want)
+	}
+	if _, err := w.Write([]byte("late")); err != os.ErrClosed {
+		t.Errorf("Write after Close = %v, want %v", err, os.ErrClosed)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("second Close = %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const compressSuffix = ".gz"
+
+// compressLogFile gzips src next to itself. The archive is written to a
+// temporary file and renamed into place before src is removed, so a crash at
+// any point leaves at least one complete copy of the data on disk.
+func compressLogFile(src string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("can't open log file for compression: %v", err)
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst := src + compressSuffix
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if err != nil {
+		return fmt.Errorf("can't create compressed log file: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(tmp)
+		}
+	}()
+
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err != nil {
+		return fmt.Errorf("failed to compress log file %q, %v", src, err)
+	}
+	if err = gz.Close(); err != nil {
+		return fmt.Errorf("failed to compress log file %q, %v", src, err)
+	}
+	if err = out.Sync(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, dst); err != nil {
+		return err
+	}
+	// Keep the original modification time so retention still orders
+	// compressed files by when they were written.
+	os.Chtimes(dst, info.ModTime(), info.ModTime())
+	in.Close()
+	return os.Remove(src)
+}
+
+func compressLogFiles(files []logFileInfo) []error {
+	var errs []error
+	for _, f := range files {
+		if strings.HasSuffix(f.path, compressSuffix) {
+			continue
+		}
+		if err := compressLogFile(f.path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
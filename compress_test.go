@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFileCompress(t *testing.T) {
+	dir := t.TempDir()
+	other := filepath.Join(dir, "other-service.log")
+	if err := os.WriteFile(other, []byte("not ours\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRotatingFile(dir, "${time}.log", "2006")
+	r.MaxSize = 16
+	r.Compress = true
+	var errs []error
+	r.ErrorHandler = func(err error) { errs = append(errs, err) }
+	for _, line := range []string{"first line 0001\n", "second line 002\n", "third line 0003\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	if data, err := os.ReadFile(other); err != nil || string(data) != "not ours\n" {
+		t.Fatalf("unrelated file was touched: %q, %v", data, err)
+	}
+	var archives []string
+	for _, name := range listDir(t, dir) {
+		if strings.HasSuffix(name, ".tmp") {
+			t.Errorf("temporary file left: %s", name)
+		}
+		if strings.HasSuffix(name, compressSuffix) {
+			archives = append(archives, name)
+		}
+	}
+	if len(archives) != 2 {
+		t.Fatalf("archives: %v", archives)
+	}
+
+	f, err := os.Open(filepath.Join(dir, archives[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "first line 0001\n" {
+		t.Errorf("archive holds %q", data)
+	}
+}
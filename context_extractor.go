@@ -0,0 +1,32 @@
+package logger
+
+import "context"
+
+// ContextExtractor returns the fields an entry should carry for ctx, such as
+// a request or tenant ID stored there by middleware.
+type ContextExtractor func(ctx context.Context) Fields
+
+// extractContextFields adds the fields of the logger's extractors to entry.
+// Fields already on the entry win over extracted ones.
+func (entry *Entry) extractContextFields() {
+	if entry.Context == nil {
+		return
+	}
+	entry.Logger.mu.Lock()
+	extractors := entry.Logger.extractors
+	entry.Logger.mu.Unlock()
+	if len(extractors) == 0 {
+		return
+	}
+
+	original := entry.Data
+	for _, extract := range extractors {
+		for k, v := range extract(entry.Context) {
+			if _, ok := original[k]; ok {
+				continue
+			}
+			entry.cloneDataOnce()
+			entry.Data[k] = v
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	ecsVersion         = "1.6.0"
+	ecsTimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+	defaultECSDataKey  = "fields"
+)
+
+type ECSFormatter struct {
+	DataKey string
+
+	ServiceName string
+
+	DisableHTMLEscape bool
+
+	PrettyPrint bool
+}
+
+func (f *ECSFormatter) Format(entry *Entry) ([]byte, error) {
+	doc := Fields{
+		"@timestamp":  entry.Time.UTC().Format(ecsTimestampFormat),
+		"log.level":   entry.Level.String(),
+		"message":     entry.Message,
+		"ecs.version": ecsVersion,
+	}
+	if f.ServiceName != "" {
+		doc["service.name"] = f.ServiceName
+	}
+	if entry.HasCaller() {
+		doc["log"] = Fields{
+			"origin": Fields{
+				"file": Fields{
+					"name": entry.Caller.File,
+					"line": entry.Caller.Line,
+				},
+				"function": entry.Caller.Function,
+			},
+		}
+	}
+
+	data := make(Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		if err, ok := v.(error); ok && k == ErrorKey {
+			doc["error"] = ecsError(err)
+			continue
+		}
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		data[k] = v
+	}
+	eachNestedStructField(entry.Field, func(key string, value interface{}) {
+		data[key] = value
+	})
+	if entry.err != "" {
+		data[FieldKeyLoggorError] = entry.err
+	}
+	if len(data) > 0 {
+		dataKey := f.DataKey
+		if dataKey == "" {
+			dataKey = defaultECSDataKey
+		}
+		doc[dataKey] = data
+	}
+
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+
+	encoder := json.NewEncoder(b)
+	encoder.SetEscapeHTML(!f.DisableHTMLEscape)
+	if f.PrettyPrint {
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to JSON, %v", err)
+	}
+
+	return b.Bytes(), nil
+}
+
+func ecsError(err error) Fields {
+	fields := Fields{
+		"message": err.Error(),
+		"type":    fmt.Sprintf("%T", err),
+	}
+	// Errors that carry a stack trace, such as those from github.com/pkg/errors,
+	// print it with the %+v verb.
+	if _, ok := err.(fmt.Formatter); ok {
+		if verbose := fmt.Sprintf("%+v", err); verbose != err.Error() {
+			fields["stack_trace"] = verbose
+		}
+	}
+	return fields
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"reflect"
 	"runtime"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -59,6 +61,12 @@ type Entry struct {
 	err string
 
 	Field interface{}
+
+	order []string
+
+	omitTime bool
+
+	dataCloned bool
 }
 
 func NewEntry(logger *Logger) *Entry {
@@ -69,7 +77,6 @@ func NewEntry(logger *Logger) *Entry {
 }
 
 func (entry *Entry) Bytes() ([]byte, error) {
-	chooseFile()
 	return entry.Logger.Formatter.Format(entry)
 }
 
@@ -91,7 +98,7 @@ func (entry *Entry) WithContext(ctx context.Context) *Entry {
 	for k, v := range entry.Data {
 		dataCopy[k] = v
 	}
-	return &Entry{Logger: entry.Logger, Data: dataCopy, Time: entry.Time, err: entry.err, Context: ctx}
+	return &Entry{Logger: entry.Logger, Data: dataCopy, Time: entry.Time, err: entry.err, Context: ctx, order: entry.order}
 }
 
 func (entry *Entry) WithField(key string, value interface{}) *Entry {
@@ -104,6 +111,12 @@ func (entry *Entry) WithFields(fields Fields) *Entry {
 		data[k] = v
 	}
 	fieldErr := entry.err
+	order := entry.order[:len(entry.order):len(entry.order)]
+	for _, k := range sortedKeys(fields) {
+		if _, ok := entry.Data[k]; !ok {
+			order = append(order, k)
+		}
+	}
 	for k, v := range fields {
 		isErrField := false
 		if t := reflect.TypeOf(v); t != nil {
@@ -125,7 +138,29 @@ func (entry *Entry) WithFields(fields Fields) *Entry {
 			data[k] = v
 		}
 	}
-	return &Entry{Logger: entry.Logger, Data: data, Time: entry.Time, err: fieldErr, Context: entry.Context}
+	return &Entry{Logger: entry.Logger, Data: data, Time: entry.Time, err: fieldErr, Context: entry.Context, order: order}
+}
+
+func (entry *Entry) orderedKeys(data Fields) []string {
+	keys := make([]string, 0, len(data))
+	seen := make(map[string]bool, len(data))
+	for _, k := range entry.order {
+		if _, ok := data[k]; ok && !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+	if len(keys) == len(data) {
+		return keys
+	}
+	rest := make([]string, 0, len(data)-len(keys))
+	for k := range data {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
 }
 
 func (entry *Entry) WithTime(t time.Time) *Entry {
@@ -133,7 +168,7 @@ func (entry *Entry) WithTime(t time.Time) *Entry {
 	for k, v := range entry.Data {
 		dataCopy[k] = v
 	}
-	return &Entry{Logger: entry.Logger, Data: dataCopy, Time: t, err: entry.err, Context: entry.Context}
+	return &Entry{Logger: entry.Logger, Data: dataCopy, Time: t, err: entry.err, Context: entry.Context, order: entry.order}
 }
 
 func getPackageName(f string) string {
@@ -151,6 +186,24 @@ func getPackageName(f string) string {
 }
 
 func getCaller() *runtime.Frame {
+	return getCallerSkipping()
+}
+
+// cloneDataOnce copies Data before log changes it, since the map is shared
+// with the Entry it came from.
+func (entry *Entry) cloneDataOnce() {
+	if entry.dataCloned {
+		return
+	}
+	data := make(Fields, len(entry.Data)+1)
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	entry.Data = data
+	entry.dataCloned = true
+}
+
+func getCallerSkipping(packages ...string) *runtime.Frame {
 	callerInitOnce.Do(func() {
 		pcs := make([]uintptr, maximumCallerDepth)
 		_ = runtime.Callers(0, pcs)
@@ -171,7 +224,7 @@ func getCaller() *runtime.Frame {
 	for f, again := frames.Next(); again; f, again = frames.Next() {
 		pkg := getPackageName(f.Function)
 
-		if pkg != loggorPackage {
+		if pkg != loggorPackage && !slices.Contains(packages, pkg) {
 			return &f
 		}
 	}
@@ -186,22 +239,43 @@ func (entry Entry) HasCaller() (has bool) {
 
 func (entry Entry) log(level Level, field interface{}, msg string) {
 	var buffer *bytes.Buffer
-	if entry.Time.IsZero() {
+	entry.dataCloned = false
+	if entry.Time.IsZero() && !entry.omitTime {
 		entry.Time = time.Now()
 	}
 	entry.Level = level
 	if len(msg) != 0 {
 		entry.Message = msg
 	}
-	if nil != field && reflect.TypeOf(field).Kind() == reflect.Struct {
+	if isStructField(field) {
 		entry.Field = field
 	}
 	entry.Logger.mu.Lock()
-	if entry.Logger.ReportCaller {
+	redactor, scrubber, limits := entry.Logger.Redactor, entry.Logger.Scrubber, entry.Logger.Limits
+	ring, router := entry.Logger.RingBuffer, entry.Logger.Router
+	entry.Logger.mu.Unlock()
+
+	entry.extractContextFields()
+	redactor.redactEntry(&entry)
+	if scrubber != nil {
+		scrubber.scrubEntry(&entry)
+	}
+	limits.apply(&entry)
+	if ring.captures(level) {
+		ring.Add(&entry)
+	}
+	enabled := entry.Logger.IsLevelEnabled(level)
+	if !enabled && !router.enabled(level) {
+		return
+	}
+	entry.Logger.mu.Lock()
+	if entry.Logger.ReportCaller && entry.Caller == nil {
 		entry.Caller = getCaller()
 	}
 	entry.Logger.mu.Unlock()
-	entry.fireHooks()
+	if enabled {
+		entry.fireHooks()
+	}
 	buffer = bufferPool.Get().(*bytes.Buffer)
 	buffer.Reset()
 	defer bufferPool.Put(buffer)
@@ -209,6 +283,8 @@ func (entry Entry) log(level Level, field interface{}, msg string) {
 	entry.write()
 	entry.Buffer = nil
 	if level <= PanicLevel {
+		ring.dump()
+		entry.Logger.flush()
 		panic(&entry)
 	}
 }
@@ -225,8 +301,11 @@ func (entry *Entry) fireHooks() {
 func (entry *Entry) write() {
 	entry.Logger.mu.Lock()
 	defer entry.Logger.mu.Unlock()
-	chooseFile()
-	serialized, err := entry.Logger.Formatter.Format(entry)
+	if entry.Logger.Router != nil {
+		entry.Logger.Router.Route(entry)
+		return
+	}
+	serialized, err := formatEntry(entry.Logger.Formatter, entry)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to obtain reader, %v\n", err)
 		return
@@ -237,7 +316,7 @@ func (entry *Entry) write() {
 }
 
 func (entry *Entry) Log(level Level, field interface{}, args ...interface{}) {
-	if entry.Logger.IsLevelEnabled(level) {
+	if entry.Logger.shouldLog(level) {
 		entry.log(level, field, fmt.Sprint(args...))
 	}
 }
@@ -281,7 +360,7 @@ func (entry *Entry) Panic(field interface{}, args ...interface{}) {
 }
 
 func (entry *Entry) Logf(level Level, field interface{}, format string, args ...interface{}) {
-	if entry.Logger.IsLevelEnabled(level) {
+	if entry.Logger.shouldLog(level) {
 		entry.Log(level, field, fmt.Sprintf(format, args...))
 	}
 }
@@ -324,7 +403,7 @@ func (entry *Entry) Panicf(field interface{}, format string, args ...interface{}
 }
 
 func (entry *Entry) Logln(level Level, field interface{}, args ...interface{}) {
-	if entry.Logger.IsLevelEnabled(level) {
+	if entry.Logger.shouldLog(level) {
 		entry.Log(level, field, entry.sprintlnn(args...))
 	}
 }
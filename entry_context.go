@@ -0,0 +1,21 @@
+package logger
+
+import "context"
+
+type entryContextKey struct{}
+
+// NewContext returns a copy of ctx that carries entry, so that code further
+// down the call chain can log with the fields gathered so far.
+func NewContext(ctx context.Context, entry *Entry) context.Context {
+	return context.WithValue(ctx, entryContextKey{}, entry)
+}
+
+// FromContext returns the entry stored in ctx by NewContext, or one of the
+// standard logger when there is none, with ctx as its Context. To add fields
+// for the layers below, store the result of WithField back with NewContext.
+func FromContext(ctx context.Context) *Entry {
+	if entry, ok := ctx.Value(entryContextKey{}).(*Entry); ok && entry != nil {
+		return entry.WithContext(ctx)
+	}
+	return StandardLogger().WithContext(ctx)
+}
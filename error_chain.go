@@ -0,0 +1,160 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxErrorChainDepth = 32
+	maxErrorChainLinks = 64
+)
+
+type errorLink struct {
+	depth int
+
+	typ string
+
+	message string
+
+	stack []uintptr
+}
+
+// errorChain walks err and the errors it wraps, depth first, following both
+// Unwrap() error and Unwrap() []error, and the Cause() error of older
+// libraries. A stack trace is kept only on the deepest error that has one in
+// each branch, since wrappers that record stacks repeat their cause's.
+func errorChain(err error) []errorLink {
+	var links []errorLink
+	walkErrorChain(err, 0, &links)
+	return links
+}
+
+func walkErrorChain(err error, depth int, links *[]errorLink) bool {
+	if err == nil || depth >= maxErrorChainDepth || len(*links) >= maxErrorChainLinks {
+		return false
+	}
+	i := len(*links)
+	*links = append(*links, errorLink{
+		depth:   depth,
+		typ:     fmt.Sprintf("%T", err),
+		message: err.Error(),
+	})
+
+	hasStack := false
+	switch wrapper := err.(type) {
+	case interface{ Unwrap() error }:
+		hasStack = walkErrorChain(wrapper.Unwrap(), depth+1, links)
+	case interface{ Unwrap() []error }:
+		for _, cause := range wrapper.Unwrap() {
+			if walkErrorChain(cause, depth+1, links) {
+				hasStack = true
+			}
+		}
+	case interface{ Cause() error }:
+		hasStack = walkErrorChain(wrapper.Cause(), depth+1, links)
+	}
+	if hasStack {
+		return true
+	}
+	if stack := errorStack(err); len(stack) > 0 {
+		(*links)[i].stack = stack
+		return true
+	}
+	return false
+}
+
+// errorStack returns the program counters of err's stack trace, from a
+// Stack() []uintptr method or a StackTrace() method returning a slice of
+// uintptr based frames, as github.com/pkg/errors does.
+func errorStack(err error) []uintptr {
+	if s, ok := err.(interface{ Stack() []uintptr }); ok {
+		return s.Stack()
+	}
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return nil
+	}
+	out := method.Call(nil)[0]
+	if out.Kind() != reflect.Slice || out.Type().Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	pcs := make([]uintptr, out.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(out.Index(i).Uint())
+	}
+	return pcs
+}
+
+func stackFrames(pcs []uintptr) []runtime.Frame {
+	var frames []runtime.Frame
+	iter := runtime.CallersFrames(pcs)
+	for {
+		frame, more := iter.Next()
+		if frame.Function != "" || frame.File != "" {
+			frames = append(frames, frame)
+		}
+		if !more {
+			return frames
+		}
+	}
+}
+
+// errorChainValue is the JSON form of err's chain: an array of objects with
+// type, message and, where there is one, stack.
+func errorChainValue(err error) []interface{} {
+	links := errorChain(err)
+	chain := make([]interface{}, len(links))
+	for i, link := range links {
+		obj := Fields{
+			"type":    link.typ,
+			"message": link.message,
+		}
+		if len(link.stack) > 0 {
+			var stack []interface{}
+			for _, frame := range stackFrames(link.stack) {
+				stack = append(stack, Fields{
+					"function": frame.Function,
+					"file":     frame.File,
+					"line":     frame.Line,
+				})
+			}
+			obj["stack"] = stack
+		}
+		chain[i] = obj
+	}
+	return chain
+}
+
+// writeErrorChain writes err's chain as indented lines below the entry, each
+// wrapped error one step further in and stack frames below the error that
+// recorded them.
+func writeErrorChain(b *bytes.Buffer, key string, err error) {
+	for _, link := range errorChain(err) {
+		indent := 4 + 2*link.depth
+		b.WriteByte('\n')
+		b.WriteString(strings.Repeat(" ", indent))
+		if link.depth == 0 {
+			b.WriteString(key)
+			b.WriteString(": ")
+		}
+		b.WriteString(link.typ)
+		b.WriteString(": ")
+		b.WriteString(strings.ReplaceAll(link.message, "\n", "\n"+strings.Repeat(" ", indent+2)))
+		for _, frame := range stackFrames(link.stack) {
+			b.WriteByte('\n')
+			b.WriteString(strings.Repeat(" ", indent+4))
+			b.WriteString("at ")
+			b.WriteString(frame.Function)
+			b.WriteString(" (")
+			b.WriteString(frame.File)
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(frame.Line))
+			b.WriteByte(')')
+		}
+	}
+}
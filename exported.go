@@ -4,19 +4,14 @@ import (
 	"context"
 	"io"
 	"os"
-	"strings"
+	"path/filepath"
 	"time"
 )
 
 var (
-	std           = New()
-	timeFlag      string
-	file          *os.File
-	localWriter   io.Writer
-	nameStr       string
-	timeStr       string
-	pathStr       string
-	chooseFileFlg bool
+	std                   = New()
+	localWriter io.Writer = os.Stderr
+	stdFile     *RotatingFile
 )
 
 func StandardLogger() *Logger {
@@ -29,39 +24,53 @@ func SetOutput(out io.Writer) {
 }
 
 func SetOutputFile(path string, nameFormatter string, timeFormatter string) {
-	timeFlag = time.Now().Format(timeFormatter)
-	file, err := os.OpenFile(path+strings.ReplaceAll(nameFormatter, "${time}", timeFlag), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if nil != err {
-		panic(err)
-	}
-	if nil != std.Out {
-		if nil != file {
-			std.SetOutput(io.MultiWriter([]io.Writer{file, localWriter}...))
-		} else {
-			std.SetOutput(io.MultiWriter([]io.Writer{file, os.Stdout}...))
-		}
-	} else {
-		std.SetOutput(file)
-	}
-	pathStr = path
-	nameStr = nameFormatter
-	timeStr = timeFormatter
-	chooseFileFlg = true
+	dir, prefix := filepath.Split(path)
+	SetOutputRotatingFile(NewRotatingFile(dir, prefix+nameFormatter, timeFormatter))
 }
 
-func chooseFile() {
-	if chooseFileFlg {
-		timeF := time.Now().Format(timeStr)
-		if file != nil && timeF != timeFlag {
-			SetOutputFile(pathStr, nameStr, timeStr)
+// SetOutputRotatingFile is SetOutputFile for a RotatingFile set up by the
+// caller, with Retention or Compress for example.
+func SetOutputRotatingFile(file *RotatingFile) {
+	if file.ErrorHandler == nil {
+		file.ErrorHandler = func(err error) {
+			std.WithError(err).Error("Failed to process rotated log file")
 		}
 	}
+	if err := file.ensureOpen(); err != nil {
+		panic(err)
+	}
+	old := stdFile
+	stdFile = file
+	std.SetOutput(io.MultiWriter(file, localWriter))
+	if old != nil {
+		old.Close()
+	}
 }
 
 func SetFormatter(formatter Formatter) {
 	std.SetFormatter(formatter)
 }
 
+func SetRouter(router *Router) {
+	std.SetRouter(router)
+}
+
+func SetRingBuffer(ring *RingBuffer) {
+	std.SetRingBuffer(ring)
+}
+
+func SetRedactor(redactor *Redactor) {
+	std.SetRedactor(redactor)
+}
+
+func SetScrubber(scrubber *Scrubber) {
+	std.SetScrubber(scrubber)
+}
+
+func SetLimits(limits *Limits) {
+	std.SetLimits(limits)
+}
+
 func SetReportCaller(include bool) {
 	std.SetReportCaller(include)
 }
@@ -82,6 +91,10 @@ func AddHook(hook Hook) {
 	std.AddHook(hook)
 }
 
+func AddContextExtractor(extractor ContextExtractor) {
+	std.AddContextExtractor(extractor)
+}
+
 func WithError(err error) *Entry {
 	return std.WithField(ErrorKey, err)
 }
@@ -1,6 +1,9 @@
 package logger
 
-import "time"
+import (
+	"sort"
+	"time"
+)
 
 const (
 	defaultTimestampFormat = time.RFC3339
@@ -10,6 +13,9 @@ const (
 	FieldKeyLoggorError    = "error"
 	FieldKeyFunc           = "func"
 	FieldKeyFile           = "file"
+	FieldKeyTraceID        = "trace_id"
+	FieldKeySpanID         = "span_id"
+	FieldKeyTraceFlags     = "trace_flags"
 )
 
 type Formatter interface {
@@ -52,3 +58,12 @@ func prefixFieldClashes(data Fields, fieldMap FieldMap, reportCaller bool) {
 		}
 	}
 }
+
+func sortedKeys(data Fields) []string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const gelfVersion = "1.1"
+
+type GELFFormatter struct {
+	Host string
+
+	DisableHTMLEscape bool
+}
+
+func (f *GELFFormatter) Format(entry *Entry) ([]byte, error) {
+	host := f.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+
+	shortMessage := entry.Message
+	if i := strings.IndexByte(shortMessage, '\n'); i >= 0 {
+		shortMessage = shortMessage[:i]
+	}
+	if shortMessage == "" {
+		shortMessage = "-"
+	}
+
+	msg := Fields{
+		"version":       gelfVersion,
+		"host":          host,
+		"short_message": shortMessage,
+		"timestamp":     float64(entry.Time.UnixNano()/int64(1e6)) / 1e3,
+		"level":         syslogSeverity(entry.Level),
+	}
+	if shortMessage != entry.Message {
+		msg["full_message"] = entry.Message
+	}
+	if entry.HasCaller() {
+		msg["_file"] = entry.Caller.File
+		msg["_line"] = entry.Caller.Line
+		msg["_function"] = entry.Caller.Function
+	}
+	if entry.err != "" {
+		msg["_"+FieldKeyLoggorError] = entry.err
+	}
+	for k, v := range entry.Data {
+		msg[gelfFieldName(k)] = gelfValue(v)
+	}
+	eachStructField(entry.Field, func(key string, value interface{}) {
+		msg[gelfFieldName(key)] = gelfValue(value)
+	})
+
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+
+	encoder := json.NewEncoder(b)
+	encoder.SetEscapeHTML(!f.DisableHTMLEscape)
+	if err := encoder.Encode(msg); err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to JSON, %v", err)
+	}
+
+	return b.Bytes(), nil
+}
+
+// gelfFieldName turns a data key into an additional field name: prefixed
+// with '_', limited to [A-Za-z0-9_.-], and never the reserved "_id".
+func gelfFieldName(key string) string {
+	name := "_" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '_', r == '.', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	if name == "_id" {
+		name = "__id"
+	}
+	return name
+}
+
+func gelfValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	case error:
+		return v.Error()
+	default:
+		return fmt.Sprint(v)
+	}
+}
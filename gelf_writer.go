@@ -0,0 +1,171 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"sync"
+)
+
+type GELFCompression uint8
+
+const (
+	GELFCompressNone GELFCompression = iota
+	GELFCompressGzip
+	GELFCompressZlib
+)
+
+const (
+	defaultGELFChunkSize = 1420
+	gelfChunkHeaderSize  = 12
+	gelfMaxChunks        = 128
+)
+
+var gelfChunkMagic = []byte{0x1e, 0x0f}
+
+type GELFWriter struct {
+	Network string
+
+	Addr string
+
+	Compression GELFCompression
+
+	ChunkSize int
+
+	mu sync.Mutex
+
+	udp net.Conn
+
+	tcp *NetWriter
+}
+
+func NewGELFWriter(network string, addr string) *GELFWriter {
+	return &GELFWriter{
+		Network:   network,
+		Addr:      addr,
+		ChunkSize: defaultGELFChunkSize,
+	}
+}
+
+// Write sends one GELF message. Over TCP messages are null-byte delimited and
+// go through a NetWriter, since Graylog doesn't accept compression there;
+// over UDP they are optionally compressed and split into chunks.
+func (w *GELFWriter) Write(p []byte) (int, error) {
+	msg := bytes.TrimRight(p, "\n")
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.Network != "udp" && w.Network != "udp4" && w.Network != "udp6" {
+		if w.tcp == nil {
+			w.tcp = NewNetWriter(w.Network, w.Addr)
+		}
+		framed := make([]byte, len(msg)+1)
+		copy(framed, msg)
+		if _, err := w.tcp.Write(framed); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if w.udp == nil {
+		conn, err := net.Dial(w.Network, w.Addr)
+		if err != nil {
+			return 0, fmt.Errorf("can't connect to graylog: %v", err)
+		}
+		w.udp = conn
+	}
+	payload, err := w.compress(msg)
+	if err != nil {
+		return 0, err
+	}
+	if err := w.writeChunked(payload); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *GELFWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.tcp != nil {
+		return w.tcp.Flush()
+	}
+	return nil
+}
+
+func (w *GELFWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var err error
+	if w.tcp != nil {
+		err = w.tcp.Close()
+		w.tcp = nil
+	}
+	if w.udp != nil {
+		err = w.udp.Close()
+		w.udp = nil
+	}
+	return err
+}
+
+func (w *GELFWriter) compress(msg []byte) ([]byte, error) {
+	var b bytes.Buffer
+	switch w.Compression {
+	case GELFCompressGzip:
+		zw := gzip.NewWriter(&b)
+		zw.Write(msg)
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+	case GELFCompressZlib:
+		zw := zlib.NewWriter(&b)
+		zw.Write(msg)
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return msg, nil
+	}
+	return b.Bytes(), nil
+}
+
+func (w *GELFWriter) writeChunked(payload []byte) error {
+	chunkSize := w.ChunkSize
+	if chunkSize <= gelfChunkHeaderSize {
+		chunkSize = defaultGELFChunkSize
+	}
+	if len(payload) <= chunkSize {
+		_, err := w.udp.Write(payload)
+		return err
+	}
+
+	dataSize := chunkSize - gelfChunkHeaderSize
+	count := (len(payload) + dataSize - 1) / dataSize
+	if count > gelfMaxChunks {
+		return fmt.Errorf("GELF message too large: %d bytes needs %d chunks", len(payload), count)
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, 0, chunkSize)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * dataSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+		chunk = append(chunk[:0], gelfChunkMagic...)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, payload[i*dataSize:end]...)
+		if _, err := w.udp.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readGELF(t *testing.T, conn net.PacketConn) []byte {
+	t.Helper()
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append([]byte(nil), buf[:n]...)
+}
+
+func TestGELFWriterUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w := NewGELFWriter("udp", conn.LocalAddr().String())
+	defer w.Close()
+	entry := NewEntry(New())
+	entry.Level = ErrorLevel
+	entry.Message = "first line\nsecond line"
+	entry.Data = Fields{"request_id": "abc", "id": 7}
+	serialized, err := (&GELFFormatter{Host: "host"}).Format(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(serialized); err != nil {
+		t.Fatal(err)
+	}
+
+	var msg map[string]interface{}
+	if err := json.Unmarshal(readGELF(t, conn), &msg); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"version":       "1.1",
+		"host":          "host",
+		"short_message": "first line",
+		"full_message":  "first line\nsecond line",
+		"level":         float64(3),
+		"_request_id":   "abc",
+		"__id":          float64(7),
+	}
+	for k, v := range want {
+		if msg[k] != v {
+			t.Errorf("%s = %v, want %v", k, msg[k], v)
+		}
+	}
+}
+
+func TestGELFWriterChunking(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w := NewGELFWriter("udp", conn.LocalAddr().String())
+	w.Compression = GELFCompressGzip
+	w.ChunkSize = 100
+	defer w.Close()
+
+	// Random-looking text doesn't compress away to a single chunk.
+	var b strings.Builder
+	for i := 0; b.Len() < 4000; i++ {
+		b.WriteString(time.Duration(i * 7919).String())
+	}
+	payload := `{"version":"1.1","short_message":"` + b.String() + `"}`
+	if _, err := w.Write([]byte(payload + "\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	var id []byte
+	var chunks [][]byte
+	for count := -1; count != len(chunks); {
+		chunk := readGELF(t, conn)
+		if len(chunk) > 100 || !bytes.HasPrefix(chunk, gelfChunkMagic) {
+			t.Fatalf("bad chunk % x", chunk[:12])
+		}
+		if id == nil {
+			id, count = chunk[2:10], int(chunk[11])
+			chunks = make([][]byte, 0, count)
+		}
+		if !bytes.Equal(chunk[2:10], id) || int(chunk[10]) != len(chunks) {
+			t.Fatalf("chunk out of order: % x", chunk[:12])
+		}
+		chunks = append(chunks, chunk[12:])
+	}
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks", len(chunks))
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(bytes.Join(chunks, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != payload {
+		t.Errorf("reassembled message differs: %q", got)
+	}
+}
@@ -0,0 +1,192 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const defaultJournalSocket = "/run/systemd/journal/socket"
+
+type JournaldSink struct {
+	Path string
+
+	Identifier string
+
+	Level Level
+
+	mu sync.Mutex
+
+	conn *net.UnixConn
+}
+
+func NewJournaldSink() *JournaldSink {
+	return &JournaldSink{
+		Path:       defaultJournalSocket,
+		Identifier: filepath.Base(os.Args[0]),
+		Level:      InfoLevel,
+	}
+}
+
+func (s *JournaldSink) Enabled(level Level) bool {
+	return s.Level >= level
+}
+
+func (s *JournaldSink) WriteEntry(entry *Entry) error {
+	b := &bytes.Buffer{}
+	writeJournalField(b, "PRIORITY", strconv.Itoa(syslogSeverity(entry.Level)))
+	writeJournalField(b, "MESSAGE", entry.Message)
+	if s.Identifier != "" {
+		writeJournalField(b, "SYSLOG_IDENTIFIER", s.Identifier)
+	}
+	if entry.HasCaller() {
+		writeJournalField(b, "CODE_FILE", entry.Caller.File)
+		writeJournalField(b, "CODE_LINE", strconv.Itoa(entry.Caller.Line))
+		writeJournalField(b, "CODE_FUNC", entry.Caller.Function)
+	}
+	for _, k := range sortedKeys(entry.Data) {
+		writeJournalField(b, journalFieldName(k), syslogValue(entry.Data[k]))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.send(b.Bytes())
+	if err != nil && !isJournalSizeError(err) {
+		// The socket may have gone away with a journald restart, so the
+		// connection is dropped and dialed again once.
+		if s.conn != nil {
+			s.conn.Close()
+			s.conn = nil
+		}
+		err = s.send(b.Bytes())
+	}
+	if isJournalSizeError(err) {
+		err = s.writeMemfd(b.Bytes())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write to journald, %v", err)
+	}
+	return nil
+}
+
+func (s *JournaldSink) send(payload []byte) error {
+	if s.conn == nil {
+		conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: s.Path, Net: "unixgram"})
+		if err != nil {
+			return fmt.Errorf("can't connect to journald: %v", err)
+		}
+		s.conn = conn
+	}
+	_, err := s.conn.Write(payload)
+	return err
+}
+
+func isJournalSizeError(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+func (s *JournaldSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// writeMemfd passes payloads that don't fit in a datagram as a sealed memfd,
+// which journald reads in place of the datagram body.
+func (s *JournaldSink) writeMemfd(payload []byte) error {
+	fd, err := unix.MemfdCreate("logger-journal", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return err
+	}
+	file := os.NewFile(uintptr(fd), "logger-journal")
+	defer file.Close()
+
+	if _, err := file.Write(payload); err != nil {
+		return err
+	}
+	seals := unix.F_SEAL_SHRINK | unix.F_SEAL_GROW | unix.F_SEAL_WRITE | unix.F_SEAL_SEAL
+	if _, err := unix.FcntlInt(file.Fd(), unix.F_ADD_SEALS, seals); err != nil {
+		return err
+	}
+	// net.UnixConn refuses WriteMsgUnix on a connected datagram socket, so
+	// the descriptor is sent with a raw sendmsg on the same socket.
+	raw, err := s.conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	rights := unix.UnixRights(int(file.Fd()))
+	var sendErr error
+	err = raw.Write(func(fd uintptr) bool {
+		sendErr = unix.Sendmsg(int(fd), nil, rights, nil, 0)
+		return sendErr != unix.EAGAIN
+	})
+	if err != nil {
+		return err
+	}
+	return sendErr
+}
+
+func writeJournalField(b *bytes.Buffer, name string, value string) {
+	b.WriteString(name)
+	if !strings.ContainsRune(value, '\n') {
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+		return
+	}
+	b.WriteByte('\n')
+	binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// journalReservedFields are written by the sink itself, so data keys with
+// these names are prefixed rather than repeated.
+var journalReservedFields = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+}
+
+// journalFieldName upper-cases a data key and drops what journald rejects:
+// anything but A-Z, 0-9 and '_', a leading '_' and a leading digit. Names the
+// sink writes itself are prefixed.
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	name = strings.TrimLeft(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') || journalReservedFields[name] {
+		name = "F_" + name
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func listenJournal(t *testing.T, path string) *net.UnixConn {
+	t.Helper()
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readJournal(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+	buf := make([]byte, 64*1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf[:n])
+}
+
+func TestJournaldSinkFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn := listenJournal(t, path)
+
+	sink := NewJournaldSink()
+	sink.Path = path
+	sink.Identifier = "test"
+	defer sink.Close()
+
+	entry := NewEntry(New())
+	entry.Level = WarnLevel
+	entry.Message = "hello"
+	entry.Data = Fields{"user_id": 7, "message": "shadow", "priority": "high"}
+	if err := sink.WriteEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readJournal(t, conn)
+	for _, want := range []string{
+		"PRIORITY=4\n",
+		"MESSAGE=hello\n",
+		"SYSLOG_IDENTIFIER=test\n",
+		"USER_ID=7\n",
+		"F_MESSAGE=shadow\n",
+		"F_PRIORITY=high\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in %q", want, got)
+		}
+	}
+	if strings.Count(got, "\nMESSAGE=") != 1 || strings.Count(got, "PRIORITY=") != 2 {
+		t.Errorf("reserved field repeated in %q", got)
+	}
+}
+
+func TestJournaldSinkRedialsAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn := listenJournal(t, path)
+
+	sink := NewJournaldSink()
+	sink.Path = path
+	defer sink.Close()
+
+	entry := NewEntry(New())
+	entry.Message = "first"
+	if err := sink.WriteEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+	readJournal(t, conn)
+
+	conn.Close()
+	os.Remove(path)
+	conn = listenJournal(t, path)
+
+	entry.Message = "second"
+	if err := sink.WriteEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+	if got := readJournal(t, conn); !strings.Contains(got, "MESSAGE=second\n") {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,294 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const jsonHex = "0123456789abcdef"
+
+type jsonField struct {
+	key   string
+	value interface{}
+}
+
+// jsonData marks the map nested under JSONFormatter.DataKey, whose top-level
+// errors are rendered with Error() like the top-level fields are.
+type jsonData Fields
+
+type jsonTimestamp struct {
+	t      time.Time
+	layout string
+}
+
+type jsonState struct {
+	fields []jsonField
+
+	scratch bytes.Buffer
+}
+
+var jsonStatePool = sync.Pool{
+	New: func() interface{} {
+		return &jsonState{fields: make([]jsonField, 0, 16)}
+	},
+}
+
+// appendJSONObject writes fields, which must already be sorted by key, as a
+// JSON object. When a key repeats the last value wins, as it would have in a
+// map.
+func appendJSONObject(b *bytes.Buffer, fields []jsonField, escapeHTML bool) error {
+	b.WriteByte('{')
+	first := true
+	for i := range fields {
+		if i+1 < len(fields) && fields[i+1].key == fields[i].key {
+			continue
+		}
+		if !first {
+			b.WriteByte(',')
+		}
+		first = false
+		appendJSONString(b, fields[i].key, escapeHTML)
+		b.WriteByte(':')
+		if err := appendJSONValue(b, fields[i].value, escapeHTML); err != nil {
+			return err
+		}
+	}
+	b.WriteByte('}')
+	return nil
+}
+
+// appendJSONValue encodes the common field types without reflection and
+// falls back to encoding/json for everything else. The output matches what
+// json.Encoder produces for the same value.
+func appendJSONValue(b *bytes.Buffer, v interface{}, escapeHTML bool) error {
+	switch v := v.(type) {
+	case nil:
+		b.WriteString("null")
+	case string:
+		appendJSONString(b, v, escapeHTML)
+	case bool:
+		b.Write(strconv.AppendBool(b.AvailableBuffer(), v))
+	case int:
+		b.Write(strconv.AppendInt(b.AvailableBuffer(), int64(v), 10))
+	case int8:
+		b.Write(strconv.AppendInt(b.AvailableBuffer(), int64(v), 10))
+	case int16:
+		b.Write(strconv.AppendInt(b.AvailableBuffer(), int64(v), 10))
+	case int32:
+		b.Write(strconv.AppendInt(b.AvailableBuffer(), int64(v), 10))
+	case int64:
+		b.Write(strconv.AppendInt(b.AvailableBuffer(), v, 10))
+	case uint:
+		b.Write(strconv.AppendUint(b.AvailableBuffer(), uint64(v), 10))
+	case uint8:
+		b.Write(strconv.AppendUint(b.AvailableBuffer(), uint64(v), 10))
+	case uint16:
+		b.Write(strconv.AppendUint(b.AvailableBuffer(), uint64(v), 10))
+	case uint32:
+		b.Write(strconv.AppendUint(b.AvailableBuffer(), uint64(v), 10))
+	case uint64:
+		b.Write(strconv.AppendUint(b.AvailableBuffer(), v, 10))
+	case float32:
+		return appendJSONFloat(b, float64(v), 32, v)
+	case float64:
+		return appendJSONFloat(b, v, 64, v)
+	case time.Time:
+		if y := v.Year(); y < 0 || y > 9999 {
+			return appendJSONFallback(b, v, escapeHTML)
+		}
+		b.WriteByte('"')
+		b.Write(v.AppendFormat(b.AvailableBuffer(), time.RFC3339Nano))
+		b.WriteByte('"')
+	case jsonTimestamp:
+		var scratch [64]byte
+		formatted := v.t.AppendFormat(scratch[:0], v.layout)
+		if jsonSafe(formatted, escapeHTML) {
+			b.WriteByte('"')
+			b.Write(formatted)
+			b.WriteByte('"')
+		} else {
+			appendJSONString(b, string(formatted), escapeHTML)
+		}
+	case []byte:
+		if v == nil {
+			b.WriteString("null")
+			return nil
+		}
+		b.WriteByte('"')
+		b.Write(base64.StdEncoding.AppendEncode(b.AvailableBuffer(), v))
+		b.WriteByte('"')
+	case jsonData:
+		return appendJSONMap(b, Fields(v), escapeHTML, true)
+	case Fields:
+		return appendJSONMap(b, v, escapeHTML, false)
+	case map[string]interface{}:
+		return appendJSONMap(b, v, escapeHTML, false)
+	case []interface{}:
+		if v == nil {
+			b.WriteString("null")
+			return nil
+		}
+		b.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := appendJSONValue(b, elem, escapeHTML); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	case []string:
+		if v == nil {
+			b.WriteString("null")
+			return nil
+		}
+		b.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			appendJSONString(b, elem, escapeHTML)
+		}
+		b.WriteByte(']')
+	default:
+		return appendJSONFallback(b, v, escapeHTML)
+	}
+	return nil
+}
+
+func appendJSONMap(b *bytes.Buffer, m map[string]interface{}, escapeHTML bool, renderErrors bool) error {
+	if m == nil {
+		b.WriteString("null")
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		appendJSONString(b, k, escapeHTML)
+		b.WriteByte(':')
+		v := m[k]
+		if err, ok := v.(error); ok && renderErrors {
+			v = err.Error()
+		}
+		if err := appendJSONValue(b, v, escapeHTML); err != nil {
+			return err
+		}
+	}
+	b.WriteByte('}')
+	return nil
+}
+
+func appendJSONFallback(b *bytes.Buffer, v interface{}, escapeHTML bool) error {
+	state := jsonStatePool.Get().(*jsonState)
+	defer jsonStatePool.Put(state)
+	state.scratch.Reset()
+
+	encoder := json.NewEncoder(&state.scratch)
+	encoder.SetEscapeHTML(escapeHTML)
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	b.Write(bytes.TrimSuffix(state.scratch.Bytes(), []byte{'\n'}))
+	return nil
+}
+
+func appendJSONFloat(b *bytes.Buffer, f float64, bits int, v interface{}) error {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return appendJSONFallback(b, v, false)
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	out := strconv.AppendFloat(b.AvailableBuffer(), f, format, -1, bits)
+	if format == 'e' {
+		// Shorten e-09 to e-9 the same way encoding/json does.
+		if n := len(out); n >= 4 && out[n-4] == 'e' && out[n-3] == '-' && out[n-2] == '0' {
+			out[n-2] = out[n-1]
+			out = out[:n-1]
+		}
+	}
+	b.Write(out)
+	return nil
+}
+
+func jsonSafe(s []byte, escapeHTML bool) bool {
+	for _, c := range s {
+		if c < 0x20 || c >= utf8.RuneSelf || c == '"' || c == '\\' || escapeHTML && (c == '<' || c == '>' || c == '&') {
+			return false
+		}
+	}
+	return true
+}
+
+func appendJSONString(b *bytes.Buffer, s string, escapeHTML bool) {
+	b.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && (!escapeHTML || c != '<' && c != '>' && c != '&') {
+				i++
+				continue
+			}
+			b.WriteString(s[start:i])
+			switch c {
+			case '\\', '"':
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			case '\b':
+				b.WriteString(`\b`)
+			case '\f':
+				b.WriteString(`\f`)
+			case '\n':
+				b.WriteString(`\n`)
+			case '\r':
+				b.WriteString(`\r`)
+			case '\t':
+				b.WriteString(`\t`)
+			default:
+				b.WriteString(`\u00`)
+				b.WriteByte(jsonHex[c>>4])
+				b.WriteByte(jsonHex[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteString(s[start:i])
+			b.WriteRune(utf8.RuneError)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b.WriteString(s[start:i])
+			b.WriteString(`\u202`)
+			b.WriteByte(jsonHex[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b.WriteString(s[start:])
+	b.WriteByte('"')
+}
@@ -4,8 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"reflect"
 	"runtime"
+	"slices"
+	"strings"
 )
 
 type fieldKey string
@@ -34,26 +35,45 @@ type JSONFormatter struct {
 	CallerPrettyfier func(*runtime.Frame) (function string, file string)
 
 	PrettyPrint bool
+
+	StructuredErrors bool
 }
 
 func (f *JSONFormatter) Format(entry *Entry) ([]byte, error) {
-	data := make(Fields, len(entry.Data)+4)
-	for k, v := range entry.Data {
-		switch v := v.(type) {
-		case error:
-			data[k] = v.Error()
-		default:
-			data[k] = v
-		}
-	}
-
+	state := jsonStatePool.Get().(*jsonState)
+	defer func() {
+		clear(state.fields)
+		state.fields = state.fields[:0]
+		jsonStatePool.Put(state)
+	}()
+
+	fields := state.fields[:0]
 	if f.DataKey != "" {
-		newData := make(Fields, 4)
-		newData[f.DataKey] = data
-		data = newData
+		data := entry.Data
+		if f.StructuredErrors {
+			data = make(Fields, len(entry.Data))
+			for k, v := range entry.Data {
+				if err, ok := v.(error); ok {
+					v = errorChainValue(err)
+				}
+				data[k] = v
+			}
+		}
+		fields = append(fields, jsonField{f.DataKey, jsonData(data)})
+	} else {
+		for k, v := range entry.Data {
+			if err, ok := v.(error); ok {
+				if f.StructuredErrors {
+					v = errorChainValue(err)
+				} else {
+					v = err.Error()
+				}
+			}
+			fields = append(fields, jsonField{k, v})
+		}
 	}
 
-	prefixFieldClashes(data, f.FieldMap, entry.HasCaller())
+	fields = prefixJSONFieldClashes(fields, f.FieldMap, entry.HasCaller())
 
 	timestampFormat := f.TimestampFormat
 	if timestampFormat == "" {
@@ -61,23 +81,16 @@ func (f *JSONFormatter) Format(entry *Entry) ([]byte, error) {
 	}
 
 	if entry.err != "" {
-		data[f.FieldMap.resolve(FieldKeyLoggorError)] = entry.err
-	}
-	if !f.DisableTimestamp {
-		data[f.FieldMap.resolve(FieldKeyTime)] = entry.Time.Format(timestampFormat)
+		fields = append(fields, jsonField{f.FieldMap.resolve(FieldKeyLoggorError), entry.err})
 	}
-	data[f.FieldMap.resolve(FieldKeyMsg)] = entry.Message
-	data[f.FieldMap.resolve(FieldKeyLevel)] = entry.Level.String()
-	if nil != entry.Field {
-		_type := reflect.TypeOf(entry.Field)
-		_value := reflect.ValueOf(entry.Field)
-		for k := 0; k < _type.NumField(); k++ {
-			key := _type.Field(k).Tag.Get("json")
-			if len(key) != 0 {
-				data[key] = _value.Field(k).Interface()
-			}
-		}
+	if !f.DisableTimestamp && !entry.omitTime {
+		fields = append(fields, jsonField{f.FieldMap.resolve(FieldKeyTime), jsonTimestamp{entry.Time, timestampFormat}})
 	}
+	fields = append(fields, jsonField{f.FieldMap.resolve(FieldKeyMsg), entry.Message})
+	fields = append(fields, jsonField{f.FieldMap.resolve(FieldKeyLevel), entry.Level.String()})
+	eachNestedStructField(entry.Field, func(key string, value interface{}) {
+		fields = append(fields, jsonField{key, value})
+	})
 	if entry.HasCaller() {
 		funcVal := entry.Caller.Function
 		fileVal := fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line)
@@ -85,12 +98,18 @@ func (f *JSONFormatter) Format(entry *Entry) ([]byte, error) {
 			funcVal, fileVal = f.CallerPrettyfier(entry.Caller)
 		}
 		if funcVal != "" {
-			data[f.FieldMap.resolve(FieldKeyFunc)] = funcVal
+			fields = append(fields, jsonField{f.FieldMap.resolve(FieldKeyFunc), funcVal})
 		}
 		if fileVal != "" {
-			data[f.FieldMap.resolve(FieldKeyFile)] = fileVal
+			fields = append(fields, jsonField{f.FieldMap.resolve(FieldKeyFile), fileVal})
 		}
 	}
+	state.fields = fields
+
+	// Stable, so the last assignment of a key still wins.
+	slices.SortStableFunc(fields, func(a, b jsonField) int {
+		return strings.Compare(a.key, b.key)
+	})
 
 	var b *bytes.Buffer
 	if entry.Buffer != nil {
@@ -99,14 +118,49 @@ func (f *JSONFormatter) Format(entry *Entry) ([]byte, error) {
 		b = &bytes.Buffer{}
 	}
 
-	encoder := json.NewEncoder(b)
-	encoder.SetEscapeHTML(!f.DisableHTMLEscape)
+	out := b
 	if f.PrettyPrint {
-		encoder.SetIndent("", "  ")
+		out = &state.scratch
+		out.Reset()
 	}
-	if err := encoder.Encode(data); err != nil {
+	start := out.Len()
+	if err := appendJSONObject(out, fields, !f.DisableHTMLEscape); err != nil {
+		out.Truncate(start)
 		return nil, fmt.Errorf("failed to marshal fields to JSON, %v", err)
 	}
+	if f.PrettyPrint {
+		if err := json.Indent(b, out.Bytes(), "", "  "); err != nil {
+			return nil, fmt.Errorf("failed to marshal fields to JSON, %v", err)
+		}
+	}
+	b.WriteByte('\n')
 
 	return b.Bytes(), nil
 }
+
+func prefixJSONFieldClashes(fields []jsonField, fieldMap FieldMap, reportCaller bool) []jsonField {
+	for _, key := range [...]fieldKey{FieldKeyTime, FieldKeyMsg, FieldKeyLevel, FieldKeyLoggorError} {
+		resolved := fieldMap.resolve(key)
+		for i := range fields {
+			if fields[i].key == resolved {
+				value := fields[i].value
+				fields = append(fields[:i], fields[i+1:]...)
+				fields = append(fields, jsonField{"fields." + resolved, value})
+				break
+			}
+		}
+	}
+
+	if reportCaller {
+		for _, key := range [...]fieldKey{FieldKeyFunc, FieldKeyFile} {
+			resolved := fieldMap.resolve(key)
+			for i := range fields {
+				if fields[i].key == resolved {
+					fields = append(fields, jsonField{"fields." + resolved, fields[i].value})
+					break
+				}
+			}
+		}
+	}
+	return fields
+}
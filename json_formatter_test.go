@@ -0,0 +1,34 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func BenchmarkJSONFormatter(b *testing.B) {
+	formatter := &JSONFormatter{}
+	entry := NewEntry(New())
+	entry.Time = time.Now()
+	entry.Level = InfoLevel
+	entry.Message = "request handled"
+	entry.Data = Fields{
+		"method":   "GET",
+		"path":     "/api/v1/users",
+		"status":   200,
+		"duration": 1.5,
+		"cached":   true,
+		"error":    errors.New("upstream timeout"),
+	}
+	entry.Buffer = &bytes.Buffer{}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entry.Buffer.Reset()
+		if _, err := formatter.Format(entry); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package logger
+
+import (
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+const (
+	truncatedKey = "_truncated"
+
+	// truncationMarkerSize is a generous estimate of the marker's length.
+	truncationMarkerSize = 32
+)
+
+// Limits bounds the size of each entry. A zero limit is no limit. Lengths
+// are in bytes and count the text kept, before the truncation marker.
+type Limits struct {
+	MaxMessageLength int
+
+	MaxValueLength int
+
+	MaxFields int
+
+	// MaxEntrySize applies to the formatted entry. Entries over it are
+	// formatted again with their longest values cut down until they fit, so
+	// the output stays well formed.
+	MaxEntrySize int
+}
+
+// apply enforces the message, value and field count limits on entry.
+func (l *Limits) apply(entry *Entry) {
+	if l == nil {
+		return
+	}
+	truncated := false
+	if l.MaxMessageLength > 0 {
+		var ok bool
+		if entry.Message, ok = truncateString(entry.Message, l.MaxMessageLength); ok {
+			truncated = true
+		}
+	}
+
+	if l.MaxFields > 0 && len(entry.Data) > l.MaxFields {
+		data := make(Fields, l.MaxFields+1)
+		for _, k := range entry.orderedKeys(entry.Data)[:l.MaxFields] {
+			data[k] = entry.Data[k]
+		}
+		entry.Data = data
+		entry.dataCloned = true
+		truncated = true
+	}
+	if l.MaxValueLength > 0 {
+		for k, v := range entry.Data {
+			var short string
+			var ok bool
+			switch v := v.(type) {
+			case string:
+				short, ok = truncateString(v, l.MaxValueLength)
+			case []byte:
+				short, ok = truncateString(string(v), l.MaxValueLength)
+			}
+			if !ok {
+				continue
+			}
+			entry.cloneDataOnce()
+			entry.Data[k] = short
+			truncated = true
+		}
+	}
+	if truncated {
+		entry.cloneDataOnce()
+		entry.Data[truncatedKey] = true
+	}
+}
+
+// formatEntry formats entry and, when the result is over the logger's
+// MaxEntrySize, formats it again with its longest strings shortened. As a
+// last resort the data and struct fields are dropped altogether.
+func formatEntry(formatter Formatter, entry *Entry) ([]byte, error) {
+	serialized, err := formatter.Format(entry)
+	var l *Limits
+	if entry.Logger != nil {
+		l = entry.Logger.Limits
+	}
+	if err != nil || l == nil || l.MaxEntrySize <= 0 || len(serialized) <= l.MaxEntrySize {
+		return serialized, err
+	}
+
+	shrunk := *entry
+	shrunk.Data = make(Fields, len(entry.Data)+1)
+	for k, v := range entry.Data {
+		shrunk.Data[k] = v
+	}
+	shrunk.Data[truncatedKey] = true
+
+	// Strings, the message among them, are cut to a common length chosen
+	// so that enough is removed, allowing for the marker each cut adds.
+	// Escaping can make the output longer than the strings, so it takes a
+	// few passes at most.
+	type candidate struct {
+		key string
+
+		value string
+
+		keep int
+	}
+	candidates := []candidate{{value: entry.Message, keep: len(entry.Message)}}
+	for k, v := range entry.Data {
+		if v, ok := v.(string); ok {
+			candidates = append(candidates, candidate{key: k, value: v, keep: len(v)})
+		}
+	}
+	removed := func(limit int) int {
+		n := 0
+		for _, c := range candidates {
+			if c.keep > limit {
+				n += c.keep - limit - truncationMarkerSize
+			}
+		}
+		return n
+	}
+
+	for pass := 0; pass < 3 && len(serialized) > l.MaxEntrySize; pass++ {
+		over := len(serialized) - l.MaxEntrySize
+		longest := 0
+		for _, c := range candidates {
+			if c.keep > longest {
+				longest = c.keep
+			}
+		}
+		limit := sort.Search(longest, func(limit int) bool {
+			return removed(limit) < over
+		}) - 1
+		if limit < 0 {
+			break
+		}
+		for i := range candidates {
+			c := &candidates[i]
+			if c.keep <= limit {
+				continue
+			}
+			c.keep = limit
+			short, _ := truncateString(c.value, limit)
+			if c.key == "" {
+				shrunk.Message = short
+			} else {
+				shrunk.Data[c.key] = short
+			}
+		}
+		if shrunk.Buffer != nil {
+			shrunk.Buffer.Reset()
+		}
+		if serialized, err = formatter.Format(&shrunk); err != nil {
+			return nil, err
+		}
+	}
+	if len(serialized) <= l.MaxEntrySize {
+		return serialized, nil
+	}
+
+	shrunk.Data = Fields{truncatedKey: true}
+	shrunk.Field = nil
+	if max := l.MaxEntrySize / 2; len(shrunk.Message) > max {
+		shrunk.Message, _ = truncateString(entry.Message, max)
+	}
+	if shrunk.Buffer != nil {
+		shrunk.Buffer.Reset()
+	}
+	return formatter.Format(&shrunk)
+}
+
+// truncateString cuts s to at most max bytes, on a rune boundary, and marks
+// how much was removed.
+func truncateString(s string, max int) (string, bool) {
+	if len(s) <= max {
+		return s, false
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…[truncated " + strconv.Itoa(len(s)-cut) + " bytes]", true
+}
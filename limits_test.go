@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLimitsApply(t *testing.T) {
+	l := New()
+	l.SetLimits(&Limits{MaxMessageLength: 5, MaxValueLength: 4, MaxFields: 2})
+	data := Fields{"a": "abcdefgh", "b": []byte("xy"), "c": 3}
+	entry := NewEntry(l).WithFields(data)
+	entry.Message = "hello world"
+	l.Limits.apply(entry)
+
+	if want := "hello…[truncated 6 bytes]"; entry.Message != want {
+		t.Errorf("Message = %q, want %q", entry.Message, want)
+	}
+	if len(entry.Data) != 3 || entry.Data["a"] != "abcd…[truncated 4 bytes]" || entry.Data[truncatedKey] != true {
+		t.Errorf("Data = %v", entry.Data)
+	}
+	if data["a"] != "abcdefgh" || len(data) != 3 {
+		t.Errorf("caller's fields were changed: %v", data)
+	}
+}
+
+func TestTruncateStringRuneBoundary(t *testing.T) {
+	got, ok := truncateString("héllo", 2)
+	if !ok || got != "h…[truncated 5 bytes]" {
+		t.Errorf("truncateString = %q, %v", got, ok)
+	}
+	if got, ok := truncateString("short", 5); ok || got != "short" {
+		t.Errorf("truncateString = %q, %v", got, ok)
+	}
+}
+
+func TestFormatEntryMaxEntrySize(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		data    Fields
+		keep    []string
+	}{
+		{
+			name:    "one long value",
+			message: "short",
+			data:    Fields{"body": strings.Repeat("x", 2000), "id": 7},
+			keep:    []string{"id"},
+		},
+		{
+			name:    "several long values",
+			message: strings.Repeat("m", 300),
+			data:    Fields{"a": strings.Repeat("a", 400), "b": strings.Repeat("b", 600), "c": "tiny"},
+			keep:    []string{"c"},
+		},
+		{
+			// Quotes double in size when escaped, so the first pass
+			// doesn't remove enough.
+			name:    "escaped values",
+			message: "quotes",
+			data:    Fields{"q": strings.Repeat(`"`, 1500)},
+		},
+		{
+			name:    "no strings to cut",
+			message: "numbers",
+			data:    Fields{"nums": make([]int, 500)},
+		},
+	}
+	for _, tt := range tests {
+		const max = 256
+		l := New()
+		l.Formatter = &JSONFormatter{}
+		l.SetLimits(&Limits{MaxEntrySize: max})
+		entry := NewEntry(l).WithFields(tt.data)
+		entry.Message = tt.message
+
+		out, err := formatEntry(l.Formatter, entry)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(out) > max {
+			t.Errorf("%s: %d bytes, want at most %d: %s", tt.name, len(out), max, out)
+		}
+		var got map[string]interface{}
+		if err := json.Unmarshal(out, &got); err != nil {
+			t.Fatalf("%s: %v: %s", tt.name, err, out)
+		}
+		if got[truncatedKey] != true {
+			t.Errorf("%s: no %s marker: %s", tt.name, truncatedKey, out)
+		}
+		for _, k := range tt.keep {
+			if _, ok := got[k]; !ok {
+				t.Errorf("%s: short field %q was dropped: %s", tt.name, k, out)
+			}
+		}
+		if _, ok := entry.Data[truncatedKey]; ok {
+			t.Errorf("%s: the entry itself was changed", tt.name)
+		}
+	}
+}
+
+func TestFormatEntryUnderLimit(t *testing.T) {
+	l := New()
+	l.Formatter = &JSONFormatter{}
+	l.SetLimits(&Limits{MaxEntrySize: 4096})
+	entry := NewEntry(l).WithField("a", "b")
+	entry.Message = "fits"
+	out, err := formatEntry(l.Formatter, entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), truncatedKey) {
+		t.Errorf("entry under the limit was marked truncated: %s", out)
+	}
+}
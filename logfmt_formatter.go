@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"time"
+	"unicode/utf8"
+)
+
+type LogfmtFormatter struct {
+	TimestampFormat string
+
+	DisableTimestamp bool
+
+	FieldMap FieldMap
+
+	CallerPrettyfier func(*runtime.Frame) (function string, file string)
+
+	KeyOrder []string
+
+	InsertionOrder bool
+}
+
+func (f *LogfmtFormatter) Format(entry *Entry) ([]byte, error) {
+	data := make(Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	prefixFieldClashes(data, f.FieldMap, entry.HasCaller())
+
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+
+	timestampFormat := f.TimestampFormat
+	if timestampFormat == "" {
+		timestampFormat = defaultTimestampFormat
+	}
+	if !f.DisableTimestamp && !entry.omitTime {
+		f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyTime), entry.Time.Format(timestampFormat))
+	}
+	f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyLevel), entry.Level.String())
+	f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyMsg), entry.Message)
+	if entry.err != "" {
+		f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyLoggorError), entry.err)
+	}
+	if entry.HasCaller() {
+		funcVal := entry.Caller.Function
+		fileVal := fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line)
+		if f.CallerPrettyfier != nil {
+			funcVal, fileVal = f.CallerPrettyfier(entry.Caller)
+		}
+		if funcVal != "" {
+			f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyFunc), funcVal)
+		}
+		if fileVal != "" {
+			f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyFile), fileVal)
+		}
+	}
+
+	for _, k := range f.KeyOrder {
+		if v, ok := data[k]; ok {
+			f.appendKeyValue(b, k, v)
+			delete(data, k)
+		}
+	}
+	var keys []string
+	if f.InsertionOrder {
+		keys = entry.orderedKeys(data)
+	} else {
+		keys = sortedKeys(data)
+	}
+	for _, k := range keys {
+		f.appendKeyValue(b, k, data[k])
+	}
+	eachStructField(entry.Field, func(key string, value interface{}) {
+		f.appendKeyValue(b, key, value)
+	})
+
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+func (f *LogfmtFormatter) appendKeyValue(b *bytes.Buffer, key string, value interface{}) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	writeLogfmtKey(b, key)
+	b.WriteByte('=')
+	switch value := value.(type) {
+	case nil:
+	case string:
+		writeLogfmtValue(b, value)
+	case error:
+		writeLogfmtValue(b, value.Error())
+	case time.Time:
+		timestampFormat := f.TimestampFormat
+		if timestampFormat == "" {
+			timestampFormat = defaultTimestampFormat
+		}
+		writeLogfmtValue(b, value.Format(timestampFormat))
+	default:
+		writeLogfmtValue(b, fmt.Sprint(value))
+	}
+}
+
+// writeLogfmtKey replaces the characters logfmt doesn't allow in a key
+// (space, '=', '"' and control characters) with '_'.
+func writeLogfmtKey(b *bytes.Buffer, key string) {
+	if key == "" {
+		b.WriteByte('_')
+		return
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			b.WriteByte('_')
+		} else {
+			b.WriteRune(r)
+		}
+	}
+}
+
+func writeLogfmtValue(b *bytes.Buffer, value string) {
+	if !logfmtNeedsQuoting(value) {
+		b.WriteString(value)
+		return
+	}
+	b.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < ' ' {
+				fmt.Fprintf(b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+}
+
+func logfmtNeedsQuoting(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError {
+			return true
+		}
+	}
+	return false
+}
@@ -16,11 +16,17 @@ type Logger struct {
 	Out          io.Writer
 	Hooks        LevelHooks
 	Formatter    Formatter
+	Router       *Router
+	RingBuffer   *RingBuffer
+	Redactor     *Redactor
+	Scrubber     *Scrubber
+	Limits       *Limits
 	ReportCaller bool
 	Level        Level
 	mu           MutexWrap
 	entryPool    sync.Pool
 	ExitFunc     exitFunc
+	extractors   []ContextExtractor
 }
 
 type exitFunc func(int)
@@ -47,7 +53,6 @@ func (mw *MutexWrap) Disable() {
 }
 
 func New() *Logger {
-	localWriter = os.Stderr
 	return &Logger{
 		Out:          os.Stderr,
 		Formatter:    new(TextFormatter),
@@ -68,6 +73,7 @@ func (logger *Logger) newEntry() *Entry {
 
 func (logger *Logger) releaseEntry(entry *Entry) {
 	entry.Data = map[string]interface{}{}
+	entry.order = nil
 	logger.entryPool.Put(entry)
 }
 
@@ -102,7 +108,7 @@ func (logger *Logger) WithTime(t time.Time) *Entry {
 }
 
 func (logger *Logger) Logf(level Level, field interface{}, format string, args ...interface{}) {
-	if logger.IsLevelEnabled(level) {
+	if logger.shouldLog(level) {
 		entry := logger.newEntry()
 		entry.Logf(level, field, format, args...)
 		logger.releaseEntry(entry)
@@ -149,7 +155,7 @@ func (logger *Logger) Panicf(field interface{}, format string, args ...interface
 }
 
 func (logger *Logger) Log(level Level, field interface{}, args ...interface{}) {
-	if logger.IsLevelEnabled(level) {
+	if logger.shouldLog(level) {
 		entry := logger.newEntry()
 		entry.Log(level, field, args...)
 		logger.releaseEntry(entry)
@@ -196,7 +202,7 @@ func (logger *Logger) Panic(field interface{}, args ...interface{}) {
 }
 
 func (logger *Logger) Logln(level Level, field interface{}, args ...interface{}) {
-	if logger.IsLevelEnabled(level) {
+	if logger.shouldLog(level) {
 		entry := logger.newEntry()
 		entry.Logln(level, field, args...)
 		logger.releaseEntry(entry)
@@ -244,12 +250,33 @@ func (logger *Logger) Panicln(field interface{}, args ...interface{}) {
 
 func (logger *Logger) Exit(code int) {
 	runHandlers()
+	logger.mu.Lock()
+	ring := logger.RingBuffer
+	logger.mu.Unlock()
+	ring.dump()
+	logger.flush()
 	if logger.ExitFunc == nil {
 		logger.ExitFunc = os.Exit
 	}
 	logger.ExitFunc(code)
 }
 
+func (logger *Logger) flush() {
+	logger.mu.Lock()
+	out, router := logger.Out, logger.Router
+	logger.mu.Unlock()
+	if f, ok := out.(flusher); ok {
+		if err := f.Flush(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to flush log, %v\n", err)
+		}
+	}
+	if router != nil {
+		if err := router.Flush(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to flush log sink, %v\n", err)
+		}
+	}
+}
+
 func (logger *Logger) SetNoLock() {
 	logger.mu.Disable()
 }
@@ -272,10 +299,26 @@ func (logger *Logger) AddHook(hook Hook) {
 	logger.Hooks.Add(hook)
 }
 
+func (logger *Logger) AddContextExtractor(extractor ContextExtractor) {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	logger.extractors = append(logger.extractors, extractor)
+}
+
 func (logger *Logger) IsLevelEnabled(level Level) bool {
 	return logger.level() >= level
 }
 
+func (logger *Logger) shouldLog(level Level) bool {
+	if logger.IsLevelEnabled(level) {
+		return true
+	}
+	logger.mu.Lock()
+	ring, router := logger.RingBuffer, logger.Router
+	logger.mu.Unlock()
+	return ring.captures(level) || router.enabled(level)
+}
+
 func (logger *Logger) SetFormatter(formatter Formatter) {
 	logger.mu.Lock()
 	defer logger.mu.Unlock()
@@ -285,10 +328,39 @@ func (logger *Logger) SetFormatter(formatter Formatter) {
 func (logger *Logger) SetOutput(output io.Writer) {
 	logger.mu.Lock()
 	defer logger.mu.Unlock()
-	localWriter = output
 	logger.Out = output
 }
 
+func (logger *Logger) SetRouter(router *Router) {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	logger.Router = router
+}
+
+func (logger *Logger) SetRingBuffer(ring *RingBuffer) {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	logger.RingBuffer = ring
+}
+
+func (logger *Logger) SetRedactor(redactor *Redactor) {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	logger.Redactor = redactor
+}
+
+func (logger *Logger) SetScrubber(scrubber *Scrubber) {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	logger.Scrubber = scrubber
+}
+
+func (logger *Logger) SetLimits(limits *Limits) {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	logger.Limits = limits
+}
+
 func (logger *Logger) SetReportCaller(reportCaller bool) {
 	logger.mu.Lock()
 	defer logger.mu.Unlock()
@@ -308,11 +380,24 @@ type Fields map[string]interface{}
 type Level uint32
 
 func (level Level) String() string {
-	if b, err := level.MarshalText(); err == nil {
-		return string(b)
-	} else {
-		return "unknown"
+	switch level {
+	case TraceLevel:
+		return "trace"
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warning"
+	case ErrorLevel:
+		return "error"
+	case FatalLevel:
+		return "fatal"
+	case PanicLevel:
+		return "panic"
 	}
+
+	return "unknown"
 }
 
 func ParseLevel(lvl string) (Level, error) {
@@ -349,23 +434,9 @@ func (level *Level) UnmarshalText(text []byte) error {
 }
 
 func (level Level) MarshalText() ([]byte, error) {
-	switch level {
-	case TraceLevel:
-		return []byte("trace"), nil
-	case DebugLevel:
-		return []byte("debug"), nil
-	case InfoLevel:
-		return []byte("info"), nil
-	case WarnLevel:
-		return []byte("warning"), nil
-	case ErrorLevel:
-		return []byte("error"), nil
-	case FatalLevel:
-		return []byte("fatal"), nil
-	case PanicLevel:
-		return []byte("panic"), nil
+	if s := level.String(); s != "unknown" {
+		return []byte(s), nil
 	}
-
 	return nil, fmt.Errorf("not a valid logger level %d", level)
 }
 
@@ -0,0 +1,34 @@
+package logger
+
+import (
+	"io"
+	"sync"
+	"testing"
+)
+
+// Run with -race: the setters must not race with entries being logged.
+func TestSettersWhileLogging(t *testing.T) {
+	logger := New()
+	logger.Out = io.Discard
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			logger.SetRedactor(NewRedactor())
+			logger.SetScrubber(NewScrubber())
+			logger.SetLimits(&Limits{MaxValueLength: 3})
+			logger.SetRingBuffer(NewRingBuffer(4, io.Discard))
+			logger.SetRouter(NewRouter(NewWriterSink(io.Discard, &JSONFormatter{}, DebugLevel)))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			logger.WithField("user", "someone@example.com").Info(nil, "logged in")
+			logger.Debug(nil, "details")
+		}
+	}()
+	wg.Wait()
+}
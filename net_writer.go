@@ -0,0 +1,405 @@
+package logger
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultNetDialTimeout  = 10 * time.Second
+	defaultNetWriteTimeout = 5 * time.Second
+	defaultNetMinBackoff   = 100 * time.Millisecond
+	defaultNetMaxBackoff   = 30 * time.Second
+	defaultNetBufferSize   = 1024
+)
+
+type NetWriter struct {
+	Network string
+
+	Addr string
+
+	TLSConfig *tls.Config
+
+	DialTimeout time.Duration
+
+	WriteTimeout time.Duration
+
+	MinBackoff time.Duration
+
+	MaxBackoff time.Duration
+
+	BufferSize int
+
+	SpoolDir string
+
+	ErrorHandler func(error)
+
+	mu sync.Mutex
+
+	drainMu sync.Mutex
+
+	conn net.Conn
+
+	pending [][]byte
+
+	spool *os.File
+
+	spoolPos *os.File
+
+	spoolOffset int64
+
+	spoolSize int64
+
+	dropped uint64
+
+	closed bool
+
+	startOnce sync.Once
+
+	wake chan struct{}
+
+	done chan struct{}
+}
+
+func NewNetWriter(network string, addr string) *NetWriter {
+	return &NetWriter{
+		Network:      network,
+		Addr:         addr,
+		DialTimeout:  defaultNetDialTimeout,
+		WriteTimeout: defaultNetWriteTimeout,
+		MinBackoff:   defaultNetMinBackoff,
+		MaxBackoff:   defaultNetMaxBackoff,
+		BufferSize:   defaultNetBufferSize,
+	}
+}
+
+// Write never blocks on the network being down, and a peer that stops reading
+// holds it up for at most WriteTimeout: each call is one record that is
+// either sent right away or queued, in order, behind whatever is already
+// waiting in memory or in the spool.
+func (w *NetWriter) Write(p []byte) (int, error) {
+	w.startOnce.Do(w.start)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.conn != nil && !w.backlogged() {
+		if done, err := w.send(p); done {
+			return len(p), err
+		}
+	}
+	if err := w.enqueue(append([]byte(nil), p...)); err != nil {
+		return 0, err
+	}
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	return len(p), nil
+}
+
+func (w *NetWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+func (w *NetWriter) Flush() error {
+	w.startOnce.Do(w.start)
+	return w.drain()
+}
+
+func (w *NetWriter) Close() error {
+	w.startOnce.Do(w.start)
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.done)
+	err := w.drain()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.dropConn()
+	if w.spool != nil {
+		w.spool.Close()
+		w.spool = nil
+	}
+	if w.spoolPos != nil {
+		w.spoolPos.Close()
+		w.spoolPos = nil
+	}
+	return err
+}
+
+func (w *NetWriter) start() {
+	w.wake = make(chan struct{}, 1)
+	w.done = make(chan struct{})
+	if w.SpoolDir != "" {
+		if err := w.openSpool(); err != nil {
+			w.reportError(err)
+		} else if w.spoolSize > 0 {
+			w.wake <- struct{}{}
+		}
+	}
+	go w.run()
+}
+
+func (w *NetWriter) run() {
+	minBackoff, maxBackoff := w.MinBackoff, w.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = defaultNetMinBackoff
+	}
+	if maxBackoff < minBackoff {
+		maxBackoff = defaultNetMaxBackoff
+	}
+	backoff := minBackoff
+	for {
+		select {
+		case <-w.wake:
+		case <-w.done:
+			return
+		}
+		for {
+			err := w.drain()
+			if err == nil {
+				backoff = minBackoff
+				break
+			}
+			w.reportError(err)
+			select {
+			case <-time.After(backoff):
+			case <-w.done:
+				return
+			}
+			if backoff *= 2; backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+	}
+}
+
+// drain connects if needed and sends everything queued, memory first since
+// records only spill to the spool once memory is full. Only one drain runs at
+// a time, so Flush and the background sender never both dial.
+func (w *NetWriter) drain() error {
+	w.drainMu.Lock()
+	defer w.drainMu.Unlock()
+
+	w.mu.Lock()
+	needConn := w.conn == nil && w.backlogged()
+	w.mu.Unlock()
+
+	if needConn {
+		conn, err := w.dial()
+		if err != nil {
+			return fmt.Errorf("can't connect to %s %s: %v", w.Network, w.Addr, err)
+		}
+		w.mu.Lock()
+		w.conn = conn
+		w.mu.Unlock()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for len(w.pending) > 0 {
+		done, err := w.send(w.pending[0])
+		if !done {
+			return err
+		}
+		if err != nil {
+			w.reportError(err)
+		}
+		w.pending[0] = nil
+		w.pending = w.pending[1:]
+	}
+	for w.spoolOffset < w.spoolSize {
+		record, err := w.readSpool()
+		if err != nil {
+			w.reportError(err)
+			w.spoolOffset = w.spoolSize
+			break
+		}
+		done, err := w.send(record)
+		if !done {
+			return err
+		}
+		if err != nil {
+			w.reportError(err)
+		}
+		w.spoolOffset += int64(4 + len(record))
+		w.saveSpoolOffset()
+	}
+	if w.spool != nil && w.spoolSize > 0 {
+		if err := w.spool.Truncate(0); err != nil {
+			return err
+		}
+		w.spoolOffset, w.spoolSize = 0, 0
+		w.saveSpoolOffset()
+	}
+	return nil
+}
+
+// send writes p as one record and reports whether it is done with, so that it
+// can leave the queue. A record cut off part way has already put some of its
+// bytes on the stream, so it is dropped along with the connection rather than
+// sent again, which would repeat them and break the framing.
+func (w *NetWriter) send(p []byte) (bool, error) {
+	if w.conn == nil {
+		return false, fmt.Errorf("not connected to %s %s", w.Network, w.Addr)
+	}
+	timeout := w.WriteTimeout
+	if timeout <= 0 {
+		timeout = defaultNetWriteTimeout
+	}
+	w.conn.SetWriteDeadline(time.Now().Add(timeout))
+	n, err := w.conn.Write(p)
+	if err == nil {
+		return true, nil
+	}
+	w.dropConn()
+	err = fmt.Errorf("failed to write to %s %s, %v", w.Network, w.Addr, err)
+	if n > 0 {
+		atomic.AddUint64(&w.dropped, 1)
+		return true, err
+	}
+	return false, err
+}
+
+func (w *NetWriter) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: w.DialTimeout}
+	if w.Network == "tls" {
+		return tls.DialWithDialer(dialer, "tcp", w.Addr, w.TLSConfig)
+	}
+	return dialer.Dial(w.Network, w.Addr)
+}
+
+func (w *NetWriter) dropConn() {
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+}
+
+func (w *NetWriter) backlogged() bool {
+	return len(w.pending) > 0 || w.spoolOffset < w.spoolSize
+}
+
+func (w *NetWriter) enqueue(p []byte) error {
+	size := w.BufferSize
+	if size <= 0 {
+		size = defaultNetBufferSize
+	}
+	if w.spoolOffset < w.spoolSize || len(w.pending) >= size {
+		if w.spool != nil {
+			return w.appendSpool(p)
+		}
+		if len(w.pending) >= size {
+			w.pending[0] = nil
+			w.pending = w.pending[1:]
+			atomic.AddUint64(&w.dropped, 1)
+		}
+	}
+	w.pending = append(w.pending, p)
+	return nil
+}
+
+func (w *NetWriter) openSpool() error {
+	if err := os.MkdirAll(w.SpoolDir, 0755); err != nil {
+		return fmt.Errorf("can't make spool directory: %v", err)
+	}
+	name := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':':
+			return '_'
+		}
+		return r
+	}, w.Network+"-"+w.Addr)
+	file, err := os.OpenFile(filepath.Join(w.SpoolDir, name+".spool"), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("can't open spool file: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	pos, err := os.OpenFile(filepath.Join(w.SpoolDir, name+".offset"), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("can't open spool offset file: %v", err)
+	}
+	w.spool, w.spoolPos = file, pos
+	w.spoolSize = info.Size()
+
+	var offset [8]byte
+	if _, err := pos.ReadAt(offset[:], 0); err == nil {
+		if n := int64(binary.BigEndian.Uint64(offset[:])); n <= w.spoolSize {
+			w.spoolOffset = n
+		}
+	}
+	return nil
+}
+
+// saveSpoolOffset records how far the spool has been sent after every
+// record, so a restart after a partial drain picks up where it stopped rather
+// than sending those records again. Only a record that was on its way when
+// the process died can be sent twice.
+func (w *NetWriter) saveSpoolOffset() {
+	var offset [8]byte
+	binary.BigEndian.PutUint64(offset[:], uint64(w.spoolOffset))
+	if _, err := w.spoolPos.WriteAt(offset[:], 0); err != nil {
+		w.reportError(fmt.Errorf("failed to write spool offset, %v", err))
+	}
+}
+
+func (w *NetWriter) appendSpool(p []byte) error {
+	record := make([]byte, 4+len(p))
+	binary.BigEndian.PutUint32(record, uint32(len(p)))
+	copy(record[4:], p)
+	n, err := w.spool.Write(record)
+	w.spoolSize += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write to spool file, %v", err)
+	}
+	return nil
+}
+
+func (w *NetWriter) readSpool() ([]byte, error) {
+	var header [4]byte
+	if _, err := w.spool.ReadAt(header[:], w.spoolOffset); err != nil {
+		return nil, fmt.Errorf("corrupt spool file: %v", err)
+	}
+	record := make([]byte, binary.BigEndian.Uint32(header[:]))
+	if _, err := w.spool.ReadAt(record, w.spoolOffset+4); err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("corrupt spool file: truncated record")
+		}
+		return nil, fmt.Errorf("corrupt spool file: %v", err)
+	}
+	return record, nil
+}
+
+func (w *NetWriter) reportError(err error) {
+	if w.ErrorHandler != nil {
+		w.ErrorHandler(err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+}
@@ -0,0 +1,184 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// downAddr returns an address nothing listens on, that a test can listen on
+// later.
+func downAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func readLines(t *testing.T, ln net.Listener, want []string) {
+	t.Helper()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	for _, line := range want {
+		got, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != line {
+			t.Fatalf("got %q, want %q", got, line)
+		}
+	}
+}
+
+func TestNetWriterSendsInOrder(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	w := NewNetWriter("tcp", ln.Addr().String())
+	defer w.Close()
+	for _, line := range []string{"one\n", "two\n", "three\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	readLines(t, ln, []string{"one\n", "two\n", "three\n"})
+}
+
+func TestNetWriterWriteTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		// The peer accepts the connection but never reads from it.
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(10 * time.Second)
+		}
+	}()
+
+	w := NewNetWriter("tcp", ln.Addr().String())
+	w.WriteTimeout = 50 * time.Millisecond
+	w.ErrorHandler = func(error) {}
+	defer w.Close()
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("connect\n"))
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	record := bytes.Repeat([]byte("x"), 1<<20)
+	for i := 0; i < 32; i++ {
+		start := time.Now()
+		w.Write(record)
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Fatalf("write %d blocked for %v", i, elapsed)
+		}
+	}
+	if w.Dropped() == 0 {
+		t.Error("no partially written record was dropped")
+	}
+}
+
+func TestNetWriterSpool(t *testing.T) {
+	addr := downAddr(t)
+	dir := t.TempDir()
+
+	w := NewNetWriter("tcp", addr)
+	w.BufferSize = 2
+	w.SpoolDir = dir
+	w.MinBackoff = 10 * time.Millisecond
+	w.ErrorHandler = func(error) {}
+	defer w.Close()
+
+	var lines []string
+	for i := 0; i < 6; i++ {
+		line := fmt.Sprintf("record %d\n", i)
+		lines = append(lines, line)
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w.Dropped() != 0 {
+		t.Fatalf("dropped %d records", w.Dropped())
+	}
+	spools, _ := filepath.Glob(filepath.Join(dir, "*.spool"))
+	if len(spools) != 1 {
+		t.Fatalf("spool files: %v", spools)
+	}
+	if info, err := os.Stat(spools[0]); err != nil || info.Size() == 0 {
+		t.Fatalf("nothing was spooled: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	readLines(t, ln, lines)
+	if info, err := os.Stat(spools[0]); err != nil || info.Size() != 0 {
+		t.Errorf("spool was not emptied: %v", err)
+	}
+}
+
+func TestNetWriterSpoolOffsetSurvivesRestart(t *testing.T) {
+	addr := downAddr(t)
+	dir := t.TempDir()
+
+	w := NewNetWriter("tcp", addr)
+	w.BufferSize = 1
+	w.SpoolDir = dir
+	w.ErrorHandler = func(error) {}
+	for i := 0; i < 4; i++ {
+		w.Write([]byte(fmt.Sprintf("record %d\n", i)))
+	}
+	// Stand in for a drain that sent the first spooled record before the
+	// connection went away again.
+	w.mu.Lock()
+	record, err := w.readSpool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.spoolOffset += int64(4 + len(record))
+	w.saveSpoolOffset()
+	w.mu.Unlock()
+	w.Close()
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	w = NewNetWriter("tcp", addr)
+	w.SpoolDir = dir
+	defer w.Close()
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	readLines(t, ln, []string{"record 2\n", "record 3\n"})
+}
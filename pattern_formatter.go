@@ -0,0 +1,403 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const defaultPatternLayout = "%time %-7level %msg %fields"
+
+var patternColors = map[string]int{
+	"red":     31,
+	"green":   32,
+	"yellow":  33,
+	"blue":    34,
+	"magenta": 35,
+	"cyan":    36,
+	"gray":    37,
+}
+
+// PatternFormatter renders entries from a layout such as
+//
+//	"%time{15:04:05.000} %-5level [%caller{short}] %msg %fields"
+//
+// Each directive may carry a width, "%5level" pads on the left and "%-5level"
+// on the right, and a maximum, "%.20msg". Supported directives are time,
+// level, msg, caller, fields, field{name}, color{name|level}, reset and
+// when{levels}{layout}, which renders layout only for the listed levels.
+// "%%" is a literal percent sign.
+type PatternFormatter struct {
+	Layout string
+
+	DisableColors bool
+
+	compileOnce sync.Once
+
+	segments []patternSegment
+
+	err error
+}
+
+type patternSegment struct {
+	literal string
+
+	render func(b *bytes.Buffer, entry *Entry)
+
+	leftAlign bool
+
+	width int
+
+	max int
+}
+
+func NewPatternFormatter(layout string) (*PatternFormatter, error) {
+	f := &PatternFormatter{Layout: layout}
+	f.compile()
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f, nil
+}
+
+func (f *PatternFormatter) compile() {
+	f.compileOnce.Do(func() {
+		layout := f.Layout
+		if layout == "" {
+			layout = defaultPatternLayout
+		}
+		f.segments, f.err = compilePattern(layout, f)
+	})
+}
+
+func (f *PatternFormatter) Format(entry *Entry) ([]byte, error) {
+	f.compile()
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+
+	renderPattern(b, entry, f.segments)
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+func renderPattern(b *bytes.Buffer, entry *Entry, segments []patternSegment) {
+	for i := range segments {
+		seg := &segments[i]
+		if seg.render == nil {
+			b.WriteString(seg.literal)
+			continue
+		}
+		start := b.Len()
+		seg.render(b, entry)
+		if seg.width == 0 && seg.max == 0 {
+			continue
+		}
+		n := utf8.RuneCount(b.Bytes()[start:])
+		if seg.max > 0 && n > seg.max {
+			cut := start
+			for j := 0; j < seg.max; j++ {
+				_, size := utf8.DecodeRune(b.Bytes()[cut:])
+				cut += size
+			}
+			b.Truncate(cut)
+			n = seg.max
+		}
+		if pad := seg.width - n; pad > 0 {
+			for j := 0; j < pad; j++ {
+				b.WriteByte(' ')
+			}
+			if !seg.leftAlign {
+				out := b.Bytes()
+				copy(out[start+pad:], out[start:len(out)-pad])
+				for j := start; j < start+pad; j++ {
+					out[j] = ' '
+				}
+			}
+		}
+	}
+}
+
+func compilePattern(layout string, f *PatternFormatter) ([]patternSegment, error) {
+	var segments []patternSegment
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			segments = append(segments, patternSegment{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(layout); {
+		if layout[i] != '%' {
+			literal.WriteByte(layout[i])
+			i++
+			continue
+		}
+		i++
+		if i < len(layout) && layout[i] == '%' {
+			literal.WriteByte('%')
+			i++
+			continue
+		}
+
+		var seg patternSegment
+		if i < len(layout) && layout[i] == '-' {
+			seg.leftAlign = true
+			i++
+		}
+		seg.width, i = parsePatternNumber(layout, i)
+		if i < len(layout) && layout[i] == '.' {
+			seg.max, i = parsePatternNumber(layout, i+1)
+		}
+		start := i
+		for i < len(layout) && (layout[i] >= 'a' && layout[i] <= 'z' || layout[i] >= 'A' && layout[i] <= 'Z') {
+			i++
+		}
+		name := layout[start:i]
+		if name == "" {
+			return nil, fmt.Errorf("pattern layout: missing directive at offset %d", start)
+		}
+		var args []string
+		for i < len(layout) && layout[i] == '{' {
+			end := matchingBrace(layout, i)
+			if end < 0 {
+				return nil, fmt.Errorf("pattern layout: unclosed '{' after %%%s", name)
+			}
+			args = append(args, layout[i+1:end])
+			i = end + 1
+		}
+
+		render, err := patternDirective(name, args, f)
+		if err != nil {
+			return nil, err
+		}
+		flush()
+		seg.render = render
+		segments = append(segments, seg)
+	}
+	flush()
+	return segments, nil
+}
+
+func parsePatternNumber(layout string, i int) (int, int) {
+	n := 0
+	for i < len(layout) && layout[i] >= '0' && layout[i] <= '9' {
+		n = n*10 + int(layout[i]-'0')
+		i++
+	}
+	return n, i
+}
+
+func matchingBrace(layout string, open int) int {
+	depth := 0
+	for i := open; i < len(layout); i++ {
+		switch layout[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func patternArg(args []string, i int, def string) string {
+	if i < len(args) && args[i] != "" {
+		return args[i]
+	}
+	return def
+}
+
+// patternDirective returns the renderer for one directive. Colors look at f
+// when rendering, so DisableColors can be set after the layout is compiled.
+func patternDirective(name string, args []string, f *PatternFormatter) (func(*bytes.Buffer, *Entry), error) {
+	switch name {
+	case "time", "d":
+		layout := patternArg(args, 0, defaultTimestampFormat)
+		return func(b *bytes.Buffer, entry *Entry) {
+			b.Write(entry.Time.AppendFormat(b.AvailableBuffer(), layout))
+		}, nil
+
+	case "level", "p":
+		upper := patternArg(args, 0, "") == "upper"
+		names := make(map[Level]string, len(AllLevels))
+		for _, level := range AllLevels {
+			names[level] = level.String()
+			if upper {
+				names[level] = strings.ToUpper(names[level])
+			}
+		}
+		return func(b *bytes.Buffer, entry *Entry) {
+			b.WriteString(names[entry.Level])
+		}, nil
+
+	case "msg", "message", "m":
+		return func(b *bytes.Buffer, entry *Entry) {
+			b.WriteString(entry.Message)
+		}, nil
+
+	case "caller":
+		mode := patternArg(args, 0, "short")
+		return func(b *bytes.Buffer, entry *Entry) {
+			if !entry.HasCaller() {
+				return
+			}
+			switch mode {
+			case "func":
+				b.WriteString(entry.Caller.Function)
+				return
+			case "long":
+				b.WriteString(entry.Caller.File)
+			default:
+				b.WriteString(filepath.Base(entry.Caller.File))
+			}
+			b.WriteByte(':')
+			b.Write(strconv.AppendInt(b.AvailableBuffer(), int64(entry.Caller.Line), 10))
+		}, nil
+
+	case "fields":
+		return func(b *bytes.Buffer, entry *Entry) {
+			state := patternFieldsPool.Get().(*patternFieldsState)
+			state.b, state.first = b, true
+			for k := range entry.Data {
+				state.keys = append(state.keys, k)
+			}
+			slices.Sort(state.keys)
+			for _, k := range state.keys {
+				state.write(k, entry.Data[k])
+			}
+			eachStructField(entry.Field, state.write)
+
+			clear(state.keys)
+			state.keys, state.b = state.keys[:0], nil
+			patternFieldsPool.Put(state)
+		}, nil
+
+	case "field":
+		key := patternArg(args, 0, "")
+		if key == "" {
+			return nil, fmt.Errorf("pattern layout: %%field needs a field name")
+		}
+		return func(b *bytes.Buffer, entry *Entry) {
+			if v, ok := entry.Data[key]; ok {
+				writePatternValue(b, v)
+				return
+			}
+			eachStructField(entry.Field, func(k string, v interface{}) {
+				if k == key {
+					writePatternValue(b, v)
+				}
+			})
+		}, nil
+
+	case "color":
+		color := patternArg(args, 0, "level")
+		code, ok := patternColors[color]
+		if !ok && color != "level" {
+			return nil, fmt.Errorf("pattern layout: unknown color %q", color)
+		}
+		return func(b *bytes.Buffer, entry *Entry) {
+			if f.DisableColors {
+				return
+			}
+			c := code
+			if color == "level" {
+				c = levelColor(entry.Level)
+			}
+			b.WriteString("\x1b[")
+			b.Write(strconv.AppendInt(b.AvailableBuffer(), int64(c), 10))
+			b.WriteByte('m')
+		}, nil
+
+	case "reset":
+		return func(b *bytes.Buffer, entry *Entry) {
+			if !f.DisableColors {
+				b.WriteString("\x1b[0m")
+			}
+		}, nil
+
+	case "when":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("pattern layout: %%when needs {levels}{layout}")
+		}
+		var levels [TraceLevel + 1]bool
+		for _, name := range strings.Split(args[0], ",") {
+			level, err := ParseLevel(strings.TrimSpace(name))
+			if err != nil {
+				return nil, fmt.Errorf("pattern layout: %v", err)
+			}
+			levels[level] = true
+		}
+		segments, err := compilePattern(args[1], f)
+		if err != nil {
+			return nil, err
+		}
+		return func(b *bytes.Buffer, entry *Entry) {
+			if int(entry.Level) < len(levels) && levels[entry.Level] {
+				renderPattern(b, entry, segments)
+			}
+		}, nil
+	}
+	return nil, fmt.Errorf("pattern layout: unknown directive %%%s", name)
+}
+
+// patternFieldsState is what %fields needs for each entry, pooled so that
+// rendering the fields doesn't allocate.
+type patternFieldsState struct {
+	keys []string
+
+	b *bytes.Buffer
+
+	first bool
+
+	write func(key string, value interface{})
+}
+
+var patternFieldsPool = sync.Pool{
+	New: func() interface{} {
+		state := &patternFieldsState{keys: make([]string, 0, 16)}
+		state.write = state.writeField
+		return state
+	},
+}
+
+func (s *patternFieldsState) writeField(key string, value interface{}) {
+	if !s.first {
+		s.b.WriteByte(' ')
+	}
+	s.first = false
+	writeLogfmtKey(s.b, key)
+	s.b.WriteByte('=')
+	writePatternValue(s.b, value)
+}
+
+func writePatternValue(b *bytes.Buffer, value interface{}) {
+	switch value := value.(type) {
+	case string:
+		writeLogfmtValue(b, value)
+	case error:
+		writeLogfmtValue(b, value.Error())
+	case int:
+		b.Write(strconv.AppendInt(b.AvailableBuffer(), int64(value), 10))
+	case bool:
+		b.Write(strconv.AppendBool(b.AvailableBuffer(), value))
+	default:
+		writeLogfmtValue(b, fmt.Sprint(value))
+	}
+}
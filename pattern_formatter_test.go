@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestPatternFormatterFields(t *testing.T) {
+	type request struct {
+		ID int `log:"id"`
+	}
+	formatter := &PatternFormatter{Layout: "%msg %fields"}
+	entry := NewEntry(New())
+	entry.Message = "done"
+	entry.Data = Fields{"b": 2, "a": "x y", "c": true}
+	entry.Field = request{ID: 9}
+
+	for i := 0; i < 2; i++ {
+		out, err := formatter.Format(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "done a=\"x y\" b=2 c=true id=9\n"; string(out) != want {
+			t.Errorf("got %q, want %q", out, want)
+		}
+	}
+
+	entry.Data = nil
+	out, err := formatter.Format(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "done id=9\n"; string(out) != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func BenchmarkPatternFormatter(b *testing.B) {
+	formatter := &PatternFormatter{Layout: "%-5level %msg %fields"}
+	entry := NewEntry(New())
+	entry.Time = time.Now()
+	entry.Level = InfoLevel
+	entry.Message = "request handled"
+	entry.Data = Fields{
+		"method": "GET",
+		"path":   "/api/v1/users",
+		"status": 200,
+		"cached": true,
+	}
+	entry.Buffer = &bytes.Buffer{}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entry.Buffer.Reset()
+		if _, err := formatter.Format(entry); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	defaultRedactMask = "[REDACTED]"
+	defaultRedactKeep = 4
+)
+
+type RedactStrategy uint8
+
+const (
+	// RedactFull replaces the whole value with the mask.
+	RedactFull RedactStrategy = iota
+	// RedactPartial replaces all but the last few characters with '*'.
+	RedactPartial
+	// RedactHash replaces the value with its SHA-256, so equal values can
+	// still be correlated.
+	RedactHash
+	// RedactDrop removes the field.
+	RedactDrop
+)
+
+// redaction is what happens to one value. keepFirst and keepLast only apply
+// to RedactPartial; when both are zero the Redactor's KeepLast is used.
+type redaction struct {
+	strategy RedactStrategy
+
+	keepFirst int
+
+	keepLast int
+}
+
+type redactRule struct {
+	key string
+
+	glob string
+
+	re *regexp.Regexp
+
+	redaction redaction
+}
+
+// Redactor masks sensitive values by key. Exact keys and globs match without
+// regard to case. Rules are checked in the order they were added and the
+// first match wins; values of keys that don't match are searched for nested
+// maps and slices.
+type Redactor struct {
+	Mask string
+
+	KeepLast int
+
+	rules []redactRule
+}
+
+func NewRedactor() *Redactor {
+	return &Redactor{
+		Mask:     defaultRedactMask,
+		KeepLast: defaultRedactKeep,
+	}
+}
+
+func (r *Redactor) AddKey(key string, strategy RedactStrategy) {
+	r.rules = append(r.rules, redactRule{key: key, redaction: redaction{strategy: strategy}})
+}
+
+// AddGlob adds a rule for keys matching a path.Match pattern such as
+// "*_token".
+func (r *Redactor) AddGlob(pattern string, strategy RedactStrategy) error {
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid redaction pattern %q: %v", pattern, err)
+	}
+	r.rules = append(r.rules, redactRule{glob: pattern, redaction: redaction{strategy: strategy}})
+	return nil
+}
+
+func (r *Redactor) AddRegexp(expr string, strategy RedactStrategy) error {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("invalid redaction pattern %q: %v", expr, err)
+	}
+	r.rules = append(r.rules, redactRule{re: re, redaction: redaction{strategy: strategy}})
+	return nil
+}
+
+func (r *Redactor) match(key string) (redaction, bool) {
+	for _, rule := range r.rules {
+		switch {
+		case rule.re != nil:
+			if rule.re.MatchString(key) {
+				return rule.redaction, true
+			}
+		case rule.glob != "":
+			if ok, _ := path.Match(rule.glob, strings.ToLower(key)); ok {
+				return rule.redaction, true
+			}
+		default:
+			if strings.EqualFold(rule.key, key) {
+				return rule.redaction, true
+			}
+		}
+	}
+	return redaction{}, false
+}
+
+// redactField returns the value to log for key, and false when the field
+// should be dropped. Flattened struct keys such as "user.password" are also
+// matched by their last segment.
+func (r *Redactor) redactField(key string, value interface{}) (interface{}, bool) {
+	red, ok := r.match(key)
+	if !ok {
+		if i := strings.LastIndexByte(key, '.'); i >= 0 {
+			red, ok = r.match(key[i+1:])
+		}
+	}
+	if ok {
+		return red.apply(value, r.Mask, r.KeepLast)
+	}
+	return r.redactValue(value), true
+}
+
+// applyTag applies a struct tag directive with r's mask settings, or the
+// defaults when there is no Redactor.
+func (r *Redactor) applyTag(red redaction, value interface{}) (interface{}, bool) {
+	if r == nil {
+		return red.apply(value, defaultRedactMask, defaultRedactKeep)
+	}
+	return red.apply(value, r.Mask, r.KeepLast)
+}
+
+func (r *Redactor) redactFields(data Fields) Fields {
+	if len(r.rules) == 0 {
+		return data
+	}
+	out := make(Fields, len(data))
+	for k, v := range data {
+		if v, ok := r.redactField(k, v); ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// redactValue copies nested maps and slices with their matching keys
+// redacted, leaving the caller's values untouched.
+func (r *Redactor) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case Fields:
+		return r.redactFields(v)
+	case map[string]interface{}:
+		return map[string]interface{}(r.redactFields(v))
+	case map[string]string:
+		out := make(map[string]string, len(v))
+		for k, s := range v {
+			if red, ok := r.redactField(k, s); ok {
+				out[k] = fmt.Sprint(red)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = r.redactValue(elem)
+		}
+		return out
+	case []Fields:
+		out := make([]Fields, len(v))
+		for i, elem := range v {
+			out[i] = r.redactFields(elem)
+		}
+		return out
+	case []map[string]interface{}:
+		out := make([]map[string]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = r.redactFields(elem)
+		}
+		return out
+	}
+	return value
+}
+
+func (red redaction) apply(value interface{}, mask string, keepLast int) (interface{}, bool) {
+	switch red.strategy {
+	case RedactDrop:
+		return nil, false
+	case RedactHash:
+		sum := sha256.Sum256([]byte(redactString(value)))
+		return "sha256:" + hex.EncodeToString(sum[:]), true
+	case RedactPartial:
+		first, last := red.keepFirst, red.keepLast
+		if first == 0 && last == 0 {
+			last = keepLast
+		}
+		return partialMask(redactString(value), first, last), true
+	}
+	if mask == "" {
+		mask = defaultRedactMask
+	}
+	return mask, true
+}
+
+func redactString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case error:
+		return v.Error()
+	}
+	return fmt.Sprint(value)
+}
+
+// partialMask replaces the runes of s with '*' except for the first and last
+// ones asked for. Values too short to keep anything back are masked whole.
+func partialMask(s string, first int, last int) string {
+	n := utf8.RuneCountInString(s)
+	if first+last >= n {
+		return strings.Repeat("*", n)
+	}
+	var b strings.Builder
+	i := 0
+	for _, c := range s {
+		if i < first || i >= n-last {
+			b.WriteRune(c)
+		} else {
+			b.WriteByte('*')
+		}
+		i++
+	}
+	return b.String()
+}
+
+// parseRedaction reads a struct tag directive: "secret", "hash", "drop" or
+// "mask=last4", "mask=first2". An unreadable mask falls back to a full mask.
+func parseRedaction(directive string) (redaction, bool) {
+	switch directive {
+	case "secret":
+		return redaction{strategy: RedactFull}, true
+	case "hash":
+		return redaction{strategy: RedactHash}, true
+	case "drop":
+		return redaction{strategy: RedactDrop}, true
+	}
+	spec, ok := strings.CutPrefix(directive, "mask=")
+	if !ok {
+		return redaction{}, false
+	}
+	red := redaction{strategy: RedactPartial}
+	if n, ok := strings.CutPrefix(spec, "last"); ok {
+		red.keepLast, _ = strconv.Atoi(n)
+	} else if n, ok := strings.CutPrefix(spec, "first"); ok {
+		red.keepFirst, _ = strconv.Atoi(n)
+	}
+	if red.keepFirst <= 0 && red.keepLast <= 0 {
+		return redaction{strategy: RedactFull}, true
+	}
+	return red, true
+}
+
+// redactEntry redacts the data and struct field of an entry as it is logged,
+// so every formatter, sink and the ring buffer see the redacted values.
+func (r *Redactor) redactEntry(entry *Entry) {
+	if r == nil {
+		return
+	}
+	entry.Data = r.redactFields(entry.Data)
+	if entry.Field != nil {
+		entry.Field = redactedStruct{field: entry.Field, redactor: r}
+	}
+}
+
+// RedactingFormatter redacts the data and struct fields of each entry before
+// handing it to Formatter, so it works with any of the formatters.
+type RedactingFormatter struct {
+	Formatter Formatter
+
+	Redactor *Redactor
+}
+
+func NewRedactingFormatter(formatter Formatter, redactor *Redactor) *RedactingFormatter {
+	return &RedactingFormatter{
+		Formatter: formatter,
+		Redactor:  redactor,
+	}
+}
+
+func (f *RedactingFormatter) Format(entry *Entry) ([]byte, error) {
+	if f.Redactor == nil {
+		return f.Formatter.Format(entry)
+	}
+	redacted := *entry
+	f.Redactor.redactEntry(&redacted)
+	return f.Formatter.Format(&redacted)
+}
+
+// redactedStruct marks an entry's struct field for eachStructField to pass
+// through a Redactor.
+type redactedStruct struct {
+	field interface{}
+
+	redactor *Redactor
+}
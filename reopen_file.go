@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+type ReopenFile struct {
+	Path string
+
+	ErrorHandler func(error)
+
+	mu sync.Mutex
+
+	file *os.File
+
+	sigCh chan os.Signal
+
+	done chan struct{}
+
+	closed bool
+}
+
+func NewReopenFile(path string, signals ...os.Signal) (*ReopenFile, error) {
+	f := &ReopenFile{Path: path}
+	if err := f.Reopen(); err != nil {
+		return nil, err
+	}
+	if len(signals) > 0 {
+		f.sigCh = make(chan os.Signal, 1)
+		f.done = make(chan struct{})
+		signal.Notify(f.sigCh, signals...)
+		go f.watchSignals(f.sigCh, f.done)
+	}
+	return f, nil
+}
+
+func (f *ReopenFile) watchSignals(sigCh <-chan os.Signal, done <-chan struct{}) {
+	for {
+		select {
+		case <-sigCh:
+			if err := f.Reopen(); err != nil && err != os.ErrClosed {
+				f.reportError(err)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+func (f *ReopenFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed || f.file == nil {
+		return 0, os.ErrClosed
+	}
+	return f.file.Write(p)
+}
+
+// Reopen swaps in a fresh descriptor for Path. Writers block on the lock for
+// the duration of the swap, so no line is split between the old and the new
+// file.
+func (f *ReopenFile) Reopen() error {
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return fmt.Errorf("can't reopen log file %q: %v", f.Path, err)
+	}
+
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		file.Close()
+		return os.ErrClosed
+	}
+	old := f.file
+	f.file = file
+	f.mu.Unlock()
+
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+func (f *ReopenFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = true
+	if f.sigCh != nil {
+		signal.Stop(f.sigCh)
+		close(f.done)
+		f.sigCh = nil
+	}
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *ReopenFile) reportError(err error) {
+	if f.ErrorHandler != nil {
+		f.ErrorHandler(err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Failed to reopen log file, %v\n", err)
+}
@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+type RetentionPolicy struct {
+	MaxAge time.Duration
+
+	MaxCount int
+
+	MaxTotalBytes int64
+
+	OnRemove func(path string, err error)
+}
+
+type logFileInfo struct {
+	path string
+	info os.FileInfo
+}
+
+func (p *RetentionPolicy) enabled() bool {
+	return p != nil && (p.MaxAge > 0 || p.MaxCount > 0 || p.MaxTotalBytes > 0)
+}
+
+func (p *RetentionPolicy) apply(dir string, pattern *logFilePattern, active string) error {
+	files, err := matchLogFiles(dir, pattern, active)
+	if err != nil {
+		return err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].info.ModTime().After(files[j].info.ModTime())
+	})
+
+	var total int64
+	if info, err := os.Stat(active); err == nil {
+		total = info.Size()
+	}
+	cutoff := time.Now().Add(-p.MaxAge)
+	for i, f := range files {
+		total += f.info.Size()
+		switch {
+		case p.MaxCount > 0 && i >= p.MaxCount,
+			p.MaxAge > 0 && f.info.ModTime().Before(cutoff),
+			p.MaxTotalBytes > 0 && total > p.MaxTotalBytes:
+			err := os.Remove(f.path)
+			if p.OnRemove != nil {
+				p.OnRemove(f.path, err)
+			}
+		}
+	}
+	return nil
+}
+
+func matchLogFiles(dir string, pattern *logFilePattern, active string) ([]logFileInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]logFileInfo, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !pattern.match(e.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if path == active {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, logFileInfo{path: path, info: info})
+	}
+	return files, nil
+}
+
+// logFilePattern matches every file a name template can produce, including
+// the numbered backups and their compressed variants. The time placeholder
+// only matches text that parses with the time layout, so other files in the
+// directory are left alone.
+type logFilePattern struct {
+	re *regexp.Regexp
+
+	timeFormatter string
+}
+
+func newLogFilePattern(nameFormatter string, timeFormatter string) *logFilePattern {
+	parts := strings.Split(filepath.Base(nameFormatter), timePlaceholder)
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	placeholder := `(.+?)`
+	if timeFormatter == "" {
+		placeholder = `()`
+	}
+	return &logFilePattern{
+		re:            regexp.MustCompile(`^` + strings.Join(parts, placeholder) + `(?:\.\d+)?(?:\.gz)?$`),
+		timeFormatter: timeFormatter,
+	}
+}
+
+func (p *logFilePattern) match(name string) bool {
+	m := p.re.FindStringSubmatch(name)
+	if m == nil {
+		return false
+	}
+	for _, timeFlag := range m[1:] {
+		if _, err := time.Parse(p.timeFormatter, timeFlag); err != nil {
+			return false
+		}
+	}
+	return true
+}
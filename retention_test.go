@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestLogFilePattern(t *testing.T) {
+	tests := []struct {
+		nameFormatter string
+		timeFormatter string
+		name          string
+		want          bool
+	}{
+		{"${time}.log", "2006-01-02", "2024-03-01.log", true},
+		{"${time}.log", "2006-01-02", "2024-03-01.log.2", true},
+		{"${time}.log", "2006-01-02", "2024-03-01.log.2.gz", true},
+		{"${time}.log", "2006-01-02", "other-service.log", false},
+		{"${time}.log", "2006-01-02", "2024-13-01.log", false},
+		{"${time}.log", "2006-01-02", "2024-03-01.log.gz.tmp", false},
+		{"app-${time}.log", "20060102", "app-20240301.log", true},
+		{"app-${time}.log", "20060102", "app-backup.log", false},
+		{"app.log", "", "app.log.1", true},
+		{"app.log", "", "my-app.log", false},
+	}
+	for _, tt := range tests {
+		p := newLogFilePattern(tt.nameFormatter, tt.timeFormatter)
+		if got := p.match(tt.name); got != tt.want {
+			t.Errorf("%q with %q: match(%q) = %v, want %v", tt.nameFormatter, tt.timeFormatter, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRetentionKeepsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	other := filepath.Join(dir, "other-service.log")
+	if err := os.WriteFile(other, []byte("not ours\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var removed []string
+	r := NewRotatingFile(dir, "${time}.log", "2006")
+	r.MaxSize = 16
+	r.Retention = &RetentionPolicy{
+		MaxCount: 1,
+		OnRemove: func(path string, err error) {
+			removed = append(removed, filepath.Base(path))
+		},
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := r.Write([]byte("0123456789abcde\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(other); err != nil {
+		t.Fatalf("unrelated file was removed: %v", err)
+	}
+	for _, name := range removed {
+		if name == "other-service.log" {
+			t.Fatal("unrelated file was passed to OnRemove")
+		}
+	}
+	// The active file, one rotated file and the unrelated file are left.
+	if names := listDir(t, dir); len(names) != 3 {
+		t.Errorf("files left: %v", names)
+	}
+}
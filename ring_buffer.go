@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync/atomic"
+)
+
+const defaultRingBufferSize = 256
+
+type RingBuffer struct {
+	Out io.Writer
+
+	Formatter Formatter
+
+	Level Level
+
+	slots []atomic.Pointer[[]byte]
+
+	next uint64
+}
+
+func NewRingBuffer(size int, out io.Writer) *RingBuffer {
+	if size <= 0 {
+		size = defaultRingBufferSize
+	}
+	return &RingBuffer{
+		Out:       out,
+		Formatter: &TextFormatter{DisableColors: true, FullTimestamp: true},
+		Level:     TraceLevel,
+		slots:     make([]atomic.Pointer[[]byte], size),
+	}
+}
+
+func (r *RingBuffer) captures(level Level) bool {
+	return r != nil && r.Level >= level
+}
+
+// Add claims the next slot with a single atomic increment, so concurrent
+// callers never wait on each other; the oldest entry is simply overwritten.
+func (r *RingBuffer) Add(entry *Entry) {
+	buffer := entry.Buffer
+	entry.Buffer = nil
+	serialized, err := r.Formatter.Format(entry)
+	entry.Buffer = buffer
+	if err != nil {
+		return
+	}
+	seq := atomic.AddUint64(&r.next, 1) - 1
+	r.slots[seq%uint64(len(r.slots))].Store(&serialized)
+}
+
+func (r *RingBuffer) Dump(w io.Writer) error {
+	end := atomic.LoadUint64(&r.next)
+	size := uint64(len(r.slots))
+	start := uint64(0)
+	if end > size {
+		start = end - size
+	}
+	for seq := start; seq < end; seq++ {
+		serialized := r.slots[seq%size].Load()
+		if serialized == nil {
+			continue
+		}
+		if _, err := w.Write(*serialized); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RingBuffer) DumpOnSignal(signals ...os.Signal) (stop func()) {
+	sigCh := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigCh, signals...)
+	go func() {
+		for {
+			select {
+			case <-sigCh:
+				r.dump()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sigCh)
+		close(done)
+	}
+}
+
+func (r *RingBuffer) dump() {
+	if r == nil || r.Out == nil {
+		return
+	}
+	if err := r.Dump(r.Out); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to dump ring buffer, %v\n", err)
+	}
+}
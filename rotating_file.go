@@ -0,0 +1,258 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const timePlaceholder = "${time}"
+
+type RotatingFile struct {
+	Path string
+
+	NameFormatter string
+
+	TimeFormatter string
+
+	MaxSize int64
+
+	Retention *RetentionPolicy
+
+	Compress bool
+
+	// ErrorHandler receives the errors of background compression and
+	// retention. They are discarded when it is nil.
+	ErrorHandler func(error)
+
+	mu sync.Mutex
+
+	millCh chan struct{}
+
+	millDone chan struct{}
+
+	file *os.File
+
+	name string
+
+	size int64
+
+	timeFlag string
+
+	closed bool
+}
+
+func NewRotatingFile(path string, nameFormatter string, timeFormatter string) *RotatingFile {
+	return &RotatingFile{
+		Path:          path,
+		NameFormatter: nameFormatter,
+		TimeFormatter: timeFormatter,
+	}
+}
+
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	switch {
+	case r.TimeFormatter != "" && r.currentTime() != r.timeFlag:
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	case r.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.MaxSize:
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return os.ErrClosed
+	}
+	if r.file == nil {
+		return r.open()
+	}
+	return r.rotate()
+}
+
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	err := r.close()
+	millCh, millDone := r.millCh, r.millDone
+	r.millCh, r.millDone = nil, nil
+	r.mu.Unlock()
+
+	if millCh != nil {
+		close(millCh)
+		<-millDone
+	}
+	return err
+}
+
+func (r *RotatingFile) ensureOpen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return os.ErrClosed
+	}
+	if r.file != nil {
+		return nil
+	}
+	return r.open()
+}
+
+func (r *RotatingFile) currentTime() string {
+	if r.TimeFormatter == "" {
+		return ""
+	}
+	return time.Now().Format(r.TimeFormatter)
+}
+
+func (r *RotatingFile) filename(timeFlag string) string {
+	return filepath.Join(r.Path, strings.ReplaceAll(r.NameFormatter, timePlaceholder, timeFlag))
+}
+
+func (r *RotatingFile) open() error {
+	timeFlag := r.currentTime()
+	name := r.filename(timeFlag)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return fmt.Errorf("can't make directory for log file %q: %v", name, err)
+	}
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file = file
+	r.name = name
+	r.size = info.Size()
+	r.timeFlag = timeFlag
+	return nil
+}
+
+func (r *RotatingFile) close() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *RotatingFile) rotate() error {
+	oldName := r.name
+	if err := r.close(); err != nil {
+		return err
+	}
+	// A file whose name doesn't change with the time flag, or one that
+	// reached MaxSize within the same period, is moved aside to a numbered
+	// backup so that the active name can be reused.
+	if r.filename(r.currentTime()) == oldName {
+		backup, err := nextBackupName(oldName)
+		if err != nil {
+			return err
+		}
+		if err := os.Rename(oldName, backup); err != nil {
+			return fmt.Errorf("can't rename log file: %v", err)
+		}
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	if r.Compress || r.Retention.enabled() {
+		r.startMill()
+	}
+	return nil
+}
+
+// startMill wakes the background goroutine that compresses rotated files and
+// applies the retention policy, starting it on first use.
+func (r *RotatingFile) startMill() {
+	if r.millCh == nil {
+		r.millCh = make(chan struct{}, 1)
+		r.millDone = make(chan struct{})
+		go r.mill(r.millCh, r.millDone)
+	}
+	select {
+	case r.millCh <- struct{}{}:
+	default:
+	}
+}
+
+func (r *RotatingFile) mill(millCh <-chan struct{}, millDone chan<- struct{}) {
+	defer close(millDone)
+	for range millCh {
+		r.millRun()
+	}
+}
+
+func (r *RotatingFile) millRun() {
+	r.mu.Lock()
+	active := r.name
+	r.mu.Unlock()
+
+	dir := filepath.Dir(active)
+	pattern := newLogFilePattern(r.NameFormatter, r.TimeFormatter)
+	if r.Compress {
+		files, err := matchLogFiles(dir, pattern, active)
+		if err != nil {
+			r.reportError(err)
+		}
+		for _, err := range compressLogFiles(files) {
+			r.reportError(err)
+		}
+	}
+	if r.Retention.enabled() {
+		if err := r.Retention.apply(dir, pattern, active); err != nil {
+			r.reportError(fmt.Errorf("failed to apply log retention, %v", err))
+		}
+	}
+}
+
+func (r *RotatingFile) reportError(err error) {
+	if r.ErrorHandler != nil {
+		r.ErrorHandler(err)
+	}
+}
+
+func nextBackupName(name string) (string, error) {
+	matches, err := filepath.Glob(name + ".*")
+	if err != nil {
+		return "", err
+	}
+	last := 0
+	for _, m := range matches {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(m, name+"."), ".gz")
+		if i, err := strconv.Atoi(suffix); err == nil && i > last {
+			last = i
+		}
+	}
+	return fmt.Sprintf("%s.%d", name, last+1), nil
+}
@@ -0,0 +1,166 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRotatingFile(dir, "app.log", "")
+	r.MaxSize = 10
+	defer r.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := listDir(t, dir), []string{"app.log", "app.log.1", "app.log.2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+	for name, want := range map[string]string{"app.log.1": "first\n", "app.log.2": "second\n", "app.log": "third\n"} {
+		if data, _ := os.ReadFile(filepath.Join(dir, name)); string(data) != want {
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
+	}
+}
+
+func TestRotatingFileMaxSizeAllowsLongRecord(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRotatingFile(dir, "app.log", "")
+	r.MaxSize = 4
+	defer r.Close()
+
+	// A record longer than MaxSize still goes into a file of its own rather
+	// than rotating away an empty one.
+	if _, err := r.Write([]byte("longer than max\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := listDir(t, dir); !reflect.DeepEqual(got, []string{"app.log"}) {
+		t.Fatalf("files = %v", got)
+	}
+}
+
+func TestRotatingFileTime(t *testing.T) {
+	dir := t.TempDir()
+	const layout = "20060102150405"
+	r := NewRotatingFile(dir, "app-${time}.log", layout)
+	defer r.Close()
+
+	if _, err := r.Write([]byte("before\n")); err != nil {
+		t.Fatal(err)
+	}
+	first := time.Now().Format(layout)
+	for time.Now().Format(layout) == first {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := r.Write([]byte("after\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	files := listDir(t, dir)
+	if len(files) != 2 {
+		t.Fatalf("files = %v, want two", files)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, files[0])); string(data) != "before\n" {
+		t.Errorf("%s = %q", files[0], data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, files[1])); string(data) != "after\n" {
+		t.Errorf("%s = %q", files[1], data)
+	}
+}
+
+func TestNextBackupName(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	for _, f := range []string{"app.log", "app.log.1", "app.log.3.gz", "app.log.old", "app.log.2.tmp"} {
+		if err := os.WriteFile(filepath.Join(dir, f), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := nextBackupName(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := name + ".4"; got != want {
+		t.Errorf("nextBackupName = %q, want %q", got, want)
+	}
+
+	got, err = nextBackupName(filepath.Join(dir, "other.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "other.log.1"); got != want {
+		t.Errorf("nextBackupName = %q, want %q", got, want)
+	}
+}
+
+func TestRotatingFileConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRotatingFile(dir, "app.log", "")
+	r.MaxSize = 256
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	written := 0
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if _, err := r.Write([]byte("0123456789\n")); err != nil {
+					if err != os.ErrClosed {
+						t.Error(err)
+					}
+					return
+				}
+				mu.Lock()
+				written++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 20; j++ {
+			if err := r.Rotate(); err != nil && err != os.ErrClosed {
+				t.Error(err)
+			}
+		}
+	}()
+	time.Sleep(5 * time.Millisecond)
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	if _, err := r.Write([]byte("late\n")); err != os.ErrClosed {
+		t.Errorf("Write after Close = %v, want %v", err, os.ErrClosed)
+	}
+	lines := 0
+	for _, name := range listDir(t, dir) {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.SplitAfter(string(data), "\n") {
+			if line == "" {
+				continue
+			}
+			if line != "0123456789\n" {
+				t.Fatalf("%s has torn line %q", name, line)
+			}
+			lines++
+		}
+	}
+	if lines != written {
+		t.Errorf("found %d lines, wrote %d", lines, written)
+	}
+}
@@ -0,0 +1,313 @@
+package logger
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// ScrubRule replaces every match of Pattern with Replacement. When Pattern has
+// a capturing group only the text of the first group is replaced, and the
+// rest of the match serves as context, such as the boundary before an
+// address. When Validate is set, only matches it accepts are replaced, for
+// patterns like card numbers that a regular expression can't check on its
+// own.
+type ScrubRule struct {
+	Name string
+
+	Pattern *regexp.Regexp
+
+	Validate func(match string) bool
+
+	Replacement string
+
+	// hint is a substring every match contains, used to skip the regular
+	// expression for strings that can't match.
+	hint string
+}
+
+var (
+	ScrubBearerToken = ScrubRule{
+		Name:        "bearer",
+		Pattern:     regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`),
+		Replacement: "Bearer [TOKEN]",
+	}
+
+	ScrubJWT = ScrubRule{
+		Name:        "jwt",
+		Pattern:     regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+		Replacement: "[JWT]",
+		hint:        "eyJ",
+	}
+
+	ScrubEmail = ScrubRule{
+		Name:        "email",
+		Pattern:     regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+		Replacement: "[EMAIL]",
+		hint:        "@",
+	}
+
+	ScrubCreditCard = ScrubRule{
+		Name:        "card",
+		Pattern:     regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		Validate:    luhnValid,
+		Replacement: "[CARD]",
+	}
+
+	// ScrubIPv6 only takes addresses standing on their own, so "std::cerr"
+	// and "Foo::Bar" are left as they are.
+	ScrubIPv6 = ScrubRule{
+		Name:        "ipv6",
+		Pattern:     regexp.MustCompile(`(?i)(?:^|[^\w:.])([0-9a-f:.]*:[0-9a-f:.]*[0-9a-f])\b`),
+		Validate:    validIPv6,
+		Replacement: "[IPV6]",
+		hint:        ":",
+	}
+
+	ScrubIPv4 = ScrubRule{
+		Name:        "ipv4",
+		Pattern:     regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\b`),
+		Replacement: "[IPV4]",
+		hint:        ".",
+	}
+)
+
+// DefaultScrubRules are the rules NewScrubber uses when it is given none.
+// Bearer tokens and JWTs go first so a token is replaced whole, and IPv6 goes
+// before IPv4 so mapped addresses aren't half replaced.
+var DefaultScrubRules = []ScrubRule{
+	ScrubBearerToken,
+	ScrubJWT,
+	ScrubEmail,
+	ScrubCreditCard,
+	ScrubIPv6,
+	ScrubIPv4,
+}
+
+// Scrubber replaces personal data found in the message and the field values
+// of each entry before it is formatted: strings, errors, the strings in nested
+// maps and slices, and the string fields of the struct field.
+type Scrubber struct {
+	rules []ScrubRule
+}
+
+func NewScrubber(rules ...ScrubRule) *Scrubber {
+	if len(rules) == 0 {
+		rules = DefaultScrubRules
+	}
+	return &Scrubber{rules: append([]ScrubRule(nil), rules...)}
+}
+
+func (s *Scrubber) AddRule(rule ScrubRule) {
+	s.rules = append(s.rules, rule)
+}
+
+func (s *Scrubber) AddRegexp(name string, expr string, replacement string) error {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("invalid scrub pattern %q: %v", expr, err)
+	}
+	s.AddRule(ScrubRule{Name: name, Pattern: re, Replacement: replacement})
+	return nil
+}
+
+func (s *Scrubber) Scrub(text string) string {
+	for i := range s.rules {
+		rule := &s.rules[i]
+		if rule.hint != "" && !strings.Contains(text, rule.hint) || !rule.Pattern.MatchString(text) {
+			continue
+		}
+		if rule.Validate == nil && rule.Pattern.NumSubexp() == 0 {
+			text = rule.Pattern.ReplaceAllLiteralString(text, rule.Replacement)
+			continue
+		}
+		text = rule.replace(text)
+	}
+	return text
+}
+
+func (rule *ScrubRule) replace(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range rule.Pattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[0], m[1]
+		if len(m) > 2 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		if rule.Validate != nil && !rule.Validate(text[start:end]) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(rule.Replacement)
+		last = end
+	}
+	if last == 0 {
+		return text
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+func (s *Scrubber) scrubEntry(entry *Entry) {
+	entry.Message = s.Scrub(entry.Message)
+	for k, v := range entry.Data {
+		if scrubbed, ok := s.scrubValue(v); ok {
+			entry.cloneDataOnce()
+			entry.Data[k] = scrubbed
+		}
+	}
+	if entry.Field != nil {
+		entry.Field = scrubbedStruct{field: entry.Field, scrubber: s}
+	}
+}
+
+// scrubValue returns value with its strings scrubbed, and false when nothing
+// changed. Errors and nested maps and slices are copied rather than changed
+// in place, as Redactor.redactValue does.
+func (s *Scrubber) scrubValue(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		scrubbed := s.Scrub(v)
+		return scrubbed, scrubbed != v
+	case error:
+		text := v.Error()
+		if scrubbed := s.Scrub(text); scrubbed != text {
+			return scrubbedError(scrubbed), true
+		}
+	case Fields:
+		if out, ok := s.scrubFields(v); ok {
+			return out, true
+		}
+	case map[string]interface{}:
+		if out, ok := s.scrubFields(v); ok {
+			return map[string]interface{}(out), true
+		}
+	case map[string]string:
+		var out map[string]string
+		for k, str := range v {
+			scrubbed := s.Scrub(str)
+			if scrubbed == str {
+				continue
+			}
+			if out == nil {
+				out = make(map[string]string, len(v))
+				for k, str := range v {
+					out[k] = str
+				}
+			}
+			out[k] = scrubbed
+		}
+		return out, out != nil
+	case []interface{}:
+		var out []interface{}
+		for i, elem := range v {
+			scrubbed, ok := s.scrubValue(elem)
+			if !ok {
+				continue
+			}
+			if out == nil {
+				out = append([]interface{}(nil), v...)
+			}
+			out[i] = scrubbed
+		}
+		return out, out != nil
+	case []string:
+		var out []string
+		for i, str := range v {
+			scrubbed := s.Scrub(str)
+			if scrubbed == str {
+				continue
+			}
+			if out == nil {
+				out = append([]string(nil), v...)
+			}
+			out[i] = scrubbed
+		}
+		return out, out != nil
+	case []Fields:
+		var out []Fields
+		for i, elem := range v {
+			scrubbed, ok := s.scrubFields(elem)
+			if !ok {
+				continue
+			}
+			if out == nil {
+				out = append([]Fields(nil), v...)
+			}
+			out[i] = scrubbed
+		}
+		return out, out != nil
+	}
+	return value, false
+}
+
+func (s *Scrubber) scrubFields(data Fields) (Fields, bool) {
+	var out Fields
+	for k, v := range data {
+		scrubbed, ok := s.scrubValue(v)
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = make(Fields, len(data))
+			for k, v := range data {
+				out[k] = v
+			}
+		}
+		out[k] = scrubbed
+	}
+	return out, out != nil
+}
+
+// scrubbedError stands in for an error whose text was scrubbed.
+type scrubbedError string
+
+func (e scrubbedError) Error() string {
+	return string(e)
+}
+
+// scrubbedStruct marks an entry's struct field for eachStructField to pass
+// through a Scrubber.
+type scrubbedStruct struct {
+	field interface{}
+
+	scrubber *Scrubber
+}
+
+// validIPv6 accepts addresses with at least two groups, or starting with
+// "::" as the loopback "::1" does.
+func validIPv6(match string) bool {
+	if net.ParseIP(match) == nil {
+		return false
+	}
+	groups := 0
+	for _, group := range strings.Split(match, ":") {
+		if group != "" {
+			groups++
+		}
+	}
+	return groups >= 2 || strings.HasPrefix(match, "::")
+}
+
+func luhnValid(match string) bool {
+	sum, digits := 0, 0
+	double := false
+	for i := len(match) - 1; i >= 0; i-- {
+		c := match[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+		digits++
+	}
+	return digits >= 13 && digits <= 19 && sum%10 == 0
+}
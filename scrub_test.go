@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestScrubIPv6(t *testing.T) {
+	s := NewScrubber(ScrubIPv6)
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"std::cerr", "std::cerr"},
+		{"Foo::Bar failed", "Foo::Bar failed"},
+		{"at main.go:42", "at main.go:42"},
+		{"started at 10:30:00", "started at 10:30:00"},
+		{"key=a:b", "key=a:b"},
+		{"dial 2001:db8::1: connection refused", "dial [IPV6]: connection refused"},
+		{"listening on ::1", "listening on [IPV6]"},
+		{"::1", "[IPV6]"},
+		{"peer fe80::1%eth0", "peer [IPV6]%eth0"},
+		{"[2001:db8:0:0:0:0:2:1]:443", "[[IPV6]]:443"},
+		{"from ::ffff:192.0.2.1.", "from [IPV6]."},
+		{"a=2001:db8::1 b=2001:db8::2", "a=[IPV6] b=[IPV6]"},
+	}
+	for _, tt := range tests {
+		if got := s.Scrub(tt.text); got != tt.want {
+			t.Errorf("Scrub(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+type scrubUser struct {
+	Name  string `log:"name"`
+	Email string `log:"email"`
+	Age   int    `log:"age"`
+}
+
+func TestScrubberFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := New()
+	l.Out = &buf
+	l.Formatter = &JSONFormatter{}
+	l.SetScrubber(NewScrubber())
+
+	nested := map[string]interface{}{"contact": "bob@example.com"}
+	l.WithFields(Fields{
+		"err":    errors.New("dial 10.0.0.1:80: refused"),
+		"nested": nested,
+		"list":   []interface{}{"alice@example.com", 3},
+		"plain":  "nothing here",
+	}).Info(scrubUser{Name: "bob", Email: "bob@example.com", Age: 30}, "user signed up")
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("%v: %s", err, buf.Bytes())
+	}
+	if got["err"] != "dial [IPV4]:80: refused" {
+		t.Errorf("err = %v", got["err"])
+	}
+	if n, _ := got["nested"].(map[string]interface{}); n["contact"] != "[EMAIL]" {
+		t.Errorf("nested = %v", got["nested"])
+	}
+	if l, _ := got["list"].([]interface{}); len(l) != 2 || l[0] != "[EMAIL]" {
+		t.Errorf("list = %v", got["list"])
+	}
+	if got["plain"] != "nothing here" {
+		t.Errorf("plain = %v", got["plain"])
+	}
+	if got["email"] != "[EMAIL]" || got["name"] != "bob" || got["age"] != 30.0 {
+		t.Errorf("struct fields = %v, %v, %v", got["name"], got["email"], got["age"])
+	}
+	if nested["contact"] != "bob@example.com" {
+		t.Errorf("caller's map was changed: %v", nested)
+	}
+}
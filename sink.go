@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+type Sink interface {
+	Enabled(level Level) bool
+	WriteEntry(entry *Entry) error
+}
+
+type WriterSink struct {
+	Out io.Writer
+
+	Formatter Formatter
+
+	Level Level
+
+	Filter func(*Entry) bool
+}
+
+func NewWriterSink(out io.Writer, formatter Formatter, level Level) *WriterSink {
+	return &WriterSink{
+		Out:       out,
+		Formatter: formatter,
+		Level:     level,
+	}
+}
+
+func (s *WriterSink) Enabled(level Level) bool {
+	return s.Level >= level
+}
+
+func (s *WriterSink) WriteEntry(entry *Entry) error {
+	if s.Filter != nil && !s.Filter(entry) {
+		return nil
+	}
+	formatter := s.Formatter
+	if formatter == nil {
+		formatter = entry.Logger.Formatter
+	}
+	serialized, err := formatEntry(formatter, entry)
+	if err != nil {
+		return fmt.Errorf("failed to obtain reader, %v", err)
+	}
+	_, err = s.Out.Write(serialized)
+	return err
+}
+
+func (s *WriterSink) Flush() error {
+	if f, ok := s.Out.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+type Router struct {
+	ErrorHandler func(Sink, error)
+
+	mu sync.RWMutex
+
+	sinks []Sink
+}
+
+func NewRouter(sinks ...Sink) *Router {
+	return &Router{sinks: sinks}
+}
+
+func (r *Router) AddSink(sink Sink) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sinks = append(r.sinks, sink)
+}
+
+func (r *Router) Sinks() []Sink {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Sink(nil), r.sinks...)
+}
+
+// enabled reports whether any sink accepts level, so that sinks can be more
+// verbose than the Logger.
+func (r *Router) enabled(level Level) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, sink := range r.sinks {
+		if sink.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Route hands the entry to every sink that accepts its level. Sinks share the
+// entry's pooled buffer, so it is reset before each one formats into it.
+func (r *Router) Route(entry *Entry) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, sink := range r.sinks {
+		if !sink.Enabled(entry.Level) {
+			continue
+		}
+		if entry.Buffer != nil {
+			entry.Buffer.Reset()
+		}
+		if err := sink.WriteEntry(entry); err != nil {
+			r.reportError(sink, err)
+		}
+	}
+}
+
+func (r *Router) Flush() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var firstErr error
+	for _, sink := range r.sinks {
+		if f, ok := sink.(flusher); ok {
+			if err := f.Flush(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func (r *Router) reportError(sink Sink, err error) {
+	if r.ErrorHandler != nil {
+		r.ErrorHandler(sink, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Failed to write to log sink, %v\n", err)
+}
@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type countingHook struct {
+	levels []Level
+
+	count int
+}
+
+func (h *countingHook) Levels() []Level {
+	return h.levels
+}
+
+func (h *countingHook) Fire(entry *Entry) error {
+	h.count++
+	return nil
+}
+
+type failingSink struct{}
+
+func (failingSink) Enabled(level Level) bool {
+	return true
+}
+
+func (failingSink) WriteEntry(entry *Entry) error {
+	return errors.New("sink down")
+}
+
+func TestRouterSinkLevels(t *testing.T) {
+	var console, file bytes.Buffer
+	logger := New()
+	logger.SetRouter(NewRouter(
+		NewWriterSink(&console, &TextFormatter{DisableColors: true}, WarnLevel),
+		NewWriterSink(&file, &JSONFormatter{}, DebugLevel),
+	))
+	hook := &countingHook{levels: AllLevels}
+	logger.AddHook(hook)
+
+	logger.Debug(nil, "debug line")
+	logger.Warn(nil, "warn line")
+
+	if strings.Contains(console.String(), "debug line") || !strings.Contains(console.String(), "warn line") {
+		t.Errorf("console got %q", console.String())
+	}
+	if !strings.Contains(file.String(), "debug line") || !strings.Contains(file.String(), "warn line") {
+		t.Errorf("file got %q", file.String())
+	}
+	// Hooks keep following Logger.Level, not the sinks.
+	if hook.count != 1 {
+		t.Errorf("hook fired %d times, want 1", hook.count)
+	}
+}
+
+func TestRouterSinkFailure(t *testing.T) {
+	var out bytes.Buffer
+	var failed []error
+	router := NewRouter(failingSink{}, &WriterSink{Out: &out, Level: InfoLevel})
+	router.ErrorHandler = func(sink Sink, err error) {
+		failed = append(failed, err)
+	}
+	logger := New()
+	logger.Formatter = &JSONFormatter{}
+	logger.SetRouter(router)
+
+	logger.Info(nil, "still written")
+
+	if len(failed) != 1 {
+		t.Errorf("errors reported: %v", failed)
+	}
+	// A WriterSink without a Formatter uses the Logger's.
+	if !strings.HasPrefix(out.String(), "{") || !strings.Contains(out.String(), "still written") {
+		t.Errorf("got %q", out.String())
+	}
+}
@@ -0,0 +1,236 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+)
+
+// SlogHandler is a slog.Handler that logs through a Logger, so libraries
+// using log/slog go through its formatters, hooks and sinks. Attributes added
+// with WithAttrs become entry fields and groups become nested Fields.
+type SlogHandler struct {
+	entry *Entry
+
+	groups []string
+}
+
+func NewSlogHandler(logger *Logger) *SlogHandler {
+	return &SlogHandler{entry: NewEntry(logger)}
+}
+
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.entry.Logger.shouldLog(levelFromSlog(level))
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := Fields{}
+	r.Attrs(func(a slog.Attr) bool {
+		addSlogAttr(attrs, a)
+		return true
+	})
+
+	entry := h.entry.WithFields(h.nest(attrs))
+	entry.Time = r.Time
+	entry.omitTime = r.Time.IsZero()
+	if ctx != nil {
+		entry.Context = ctx
+	}
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		entry.Caller = &frame
+	}
+	entry.Log(levelFromSlog(r.Level), nil, r.Message)
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := Fields{}
+	for _, a := range attrs {
+		addSlogAttr(fields, a)
+	}
+	if len(fields) == 0 {
+		return h
+	}
+	return &SlogHandler{entry: h.entry.WithFields(h.nest(fields)), groups: h.groups}
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	groups := append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &SlogHandler{entry: h.entry, groups: groups}
+}
+
+// nest places fields under the handler's open groups, merged with what the
+// entry already holds there, ready for WithFields. Groups with nothing in
+// them are left out.
+func (h *SlogHandler) nest(fields Fields) Fields {
+	if len(fields) == 0 || len(h.groups) == 0 {
+		return fields
+	}
+	top := Fields{}
+	parent, existing := top, h.entry.Data
+	for i, name := range h.groups {
+		group := Fields{}
+		old, _ := existing[name].(Fields)
+		for k, v := range old {
+			group[k] = v
+		}
+		if i == len(h.groups)-1 {
+			for k, v := range fields {
+				if err, ok := v.(error); ok {
+					v = err.Error()
+				}
+				group[k] = v
+			}
+		}
+		parent[name] = group
+		parent, existing = group, old
+	}
+	return top
+}
+
+func addSlogAttr(fields Fields, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		fields[a.Key] = slogValue(a.Value)
+		return
+	}
+	attrs := a.Value.Group()
+	if len(attrs) == 0 {
+		return
+	}
+	if a.Key == "" {
+		for _, attr := range attrs {
+			addSlogAttr(fields, attr)
+		}
+		return
+	}
+	group := Fields{}
+	for _, attr := range attrs {
+		addSlogAttr(group, attr)
+	}
+	if len(group) == 0 {
+		return
+	}
+	// The formatters only render errors at the top level, so those in
+	// groups are turned into their message here.
+	for k, v := range group {
+		if err, ok := v.(error); ok {
+			group[k] = err.Error()
+		}
+	}
+	fields[a.Key] = group
+}
+
+func slogValue(v slog.Value) interface{} {
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String()
+	case slog.KindInt64:
+		return v.Int64()
+	case slog.KindUint64:
+		return v.Uint64()
+	case slog.KindFloat64:
+		return v.Float64()
+	case slog.KindBool:
+		return v.Bool()
+	case slog.KindDuration:
+		return v.Duration()
+	case slog.KindTime:
+		return v.Time()
+	}
+	return v.Any()
+}
+
+func levelFromSlog(level slog.Level) Level {
+	switch {
+	case level < slog.LevelDebug:
+		return TraceLevel
+	case level < slog.LevelInfo:
+		return DebugLevel
+	case level < slog.LevelWarn:
+		return InfoLevel
+	case level < slog.LevelError:
+		return WarnLevel
+	}
+	return ErrorLevel
+}
+
+func levelToSlog(level Level) slog.Level {
+	switch level {
+	case TraceLevel:
+		return slog.LevelDebug - 4
+	case DebugLevel:
+		return slog.LevelDebug
+	case InfoLevel:
+		return slog.LevelInfo
+	case WarnLevel:
+		return slog.LevelWarn
+	case ErrorLevel:
+		return slog.LevelError
+	case FatalLevel:
+		return slog.LevelError + 4
+	}
+	return slog.LevelError + 8
+}
+
+// SlogSink forwards entries to a slog.Handler. Data becomes attributes, with
+// nested Fields as groups, and the struct field's fields are added after it.
+type SlogSink struct {
+	Handler slog.Handler
+}
+
+func NewSlogSink(handler slog.Handler) *SlogSink {
+	return &SlogSink{Handler: handler}
+}
+
+func (s *SlogSink) Enabled(level Level) bool {
+	return s.Handler.Enabled(context.Background(), levelToSlog(level))
+}
+
+func (s *SlogSink) WriteEntry(entry *Entry) error {
+	var pc uintptr
+	if entry.HasCaller() {
+		pc = entry.Caller.PC
+	}
+	r := slog.NewRecord(entry.Time, levelToSlog(entry.Level), entry.Message, pc)
+	for _, k := range entry.orderedKeys(entry.Data) {
+		r.AddAttrs(slogAttr(k, entry.Data[k]))
+	}
+	if entry.err != "" {
+		r.AddAttrs(slog.String(FieldKeyLoggorError, entry.err))
+	}
+	eachNestedStructField(entry.Field, func(key string, value interface{}) {
+		r.AddAttrs(slogAttr(key, value))
+	})
+
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return s.Handler.Handle(ctx, r)
+}
+
+func slogAttr(key string, value interface{}) slog.Attr {
+	var group map[string]interface{}
+	switch v := value.(type) {
+	case Fields:
+		group = v
+	case map[string]interface{}:
+		group = v
+	default:
+		return slog.Any(key, value)
+	}
+	keys := sortedKeys(group)
+	attrs := make([]interface{}, len(keys))
+	for i, k := range keys {
+		attrs[i] = slogAttr(k, group[k])
+	}
+	return slog.Group(key, attrs...)
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"testing/slogtest"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New()
+	logger.Out = &buf
+	logger.Level = TraceLevel
+	logger.Formatter = &JSONFormatter{
+		FieldMap: FieldMap{
+			FieldKeyTime: slog.TimeKey,
+			FieldKeyMsg:  slog.MessageKey,
+		},
+	}
+
+	results := func() []map[string]interface{} {
+		var ms []map[string]interface{}
+		for _, line := range bytes.Split(buf.Bytes(), []byte{'\n'}) {
+			if len(line) == 0 {
+				continue
+			}
+			var m map[string]interface{}
+			if err := json.Unmarshal(line, &m); err != nil {
+				t.Fatal(err)
+			}
+			ms = append(ms, m)
+		}
+		return ms
+	}
+	if err := slogtest.TestHandler(NewSlogHandler(logger), results); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSlogSink(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New()
+	logger.SetRouter(NewRouter(NewSlogSink(slog.NewJSONHandler(&buf, nil))))
+
+	logger.WithFields(Fields{"user": "ann", "req": Fields{"id": 7}}).Warn(nil, "slow request")
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["level"] != "WARN" || m["msg"] != "slow request" || m["user"] != "ann" {
+		t.Errorf("unexpected record %v", m)
+	}
+	if req, ok := m["req"].(map[string]interface{}); !ok || req["id"] != float64(7) {
+		t.Errorf("group not kept: %v", m["req"])
+	}
+}
@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	stdLogSourceKey = "source"
+	stdLogSource    = "stdlog"
+	stdLogLevels    = `(?i:trace|debug|info|warn|warning|error|err|fatal|panic|crit|critical)`
+)
+
+var stdLogFileRe = regexp.MustCompile(`^\S*:\d+: `)
+
+// StdLogRule recognises the level of a line written through the log
+// package. The text Pattern matches is removed from the message. The level
+// comes from the pattern's "level" group when it has one and the group holds
+// a level name, and from Level otherwise.
+type StdLogRule struct {
+	Pattern *regexp.Regexp
+
+	Level Level
+}
+
+// DefaultStdLogRules recognise "[ERROR] ...", "WARN: ..." and
+// "... level=debug ...".
+var DefaultStdLogRules = []StdLogRule{
+	{Pattern: regexp.MustCompile(`^\[(?P<level>` + stdLogLevels + `)\]\s*`)},
+	{Pattern: regexp.MustCompile(`^(?P<level>` + stdLogLevels + `):\s*`)},
+	{Pattern: regexp.MustCompile(`(?:^|\s)level=(?P<level>` + stdLogLevels + `)\b\s*`)},
+}
+
+// StdLogWriter turns the lines written by a standard library *log.Logger into
+// entries, at the level its rules find and with a source=stdlog field. The
+// log package's own date, time, file and prefix are removed, since the entry
+// carries its own.
+type StdLogWriter struct {
+	Logger *Logger
+
+	Rules []StdLogRule
+
+	DefaultLevel Level
+
+	mu sync.Mutex
+
+	std *log.Logger
+}
+
+func NewStdLogWriter(logger *Logger) *StdLogWriter {
+	return &StdLogWriter{
+		Logger:       logger,
+		Rules:        DefaultStdLogRules,
+		DefaultLevel: InfoLevel,
+	}
+}
+
+// RedirectStdLog sends the output of the standard log package to logger.
+func RedirectStdLog(logger *Logger) *StdLogWriter {
+	w := NewStdLogWriter(logger)
+	w.Redirect(log.Default())
+	return w
+}
+
+// Redirect points std at w. The flags and prefix of std are read on every
+// write, so they can still be changed afterwards.
+func (w *StdLogWriter) Redirect(std *log.Logger) {
+	w.mu.Lock()
+	w.std = std
+	w.mu.Unlock()
+	std.SetOutput(w)
+}
+
+// Write logs p as one entry. The log package makes one Write per message, so
+// a message spanning several lines stays together.
+func (w *StdLogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	std := w.std
+	w.mu.Unlock()
+
+	line := strings.TrimSuffix(string(p), "\n")
+	if std != nil {
+		line = stripStdLogHeader(line, std.Flags(), std.Prefix())
+	}
+	level, msg := w.detectLevel(line)
+
+	entry := w.Logger.WithField(stdLogSourceKey, stdLogSource)
+	if w.Logger.ReportCaller {
+		entry.Caller = getCallerSkipping("log")
+	}
+	entry.Log(level, nil, msg)
+	return len(p), nil
+}
+
+func (w *StdLogWriter) detectLevel(line string) (Level, string) {
+	for _, rule := range w.Rules {
+		loc := rule.Pattern.FindStringSubmatchIndex(line)
+		if loc == nil {
+			continue
+		}
+		level := rule.Level
+		if i := rule.Pattern.SubexpIndex("level"); i > 0 && loc[2*i] >= 0 {
+			if parsed, ok := parseStdLogLevel(line[loc[2*i]:loc[2*i+1]]); ok {
+				level = parsed
+			}
+		}
+		msg := strings.TrimSpace(line[:loc[0]] + " " + line[loc[1]:])
+		return capStdLogLevel(level), msg
+	}
+	return capStdLogLevel(w.DefaultLevel), line
+}
+
+func parseStdLogLevel(name string) (Level, bool) {
+	switch strings.ToLower(name) {
+	case "err":
+		return ErrorLevel, true
+	case "crit", "critical":
+		return FatalLevel, true
+	}
+	level, err := ParseLevel(name)
+	return level, err == nil
+}
+
+// capStdLogLevel keeps fatal and panic lines at error. log.Fatal and
+// log.Panic exit or panic by themselves after writing, and a third party
+// line that merely says FATAL shouldn't stop the program.
+func capStdLogLevel(level Level) Level {
+	if level < ErrorLevel {
+		return ErrorLevel
+	}
+	return level
+}
+
+// stripStdLogHeader removes what log.Logger puts before the message for the
+// given flags and prefix.
+func stripStdLogHeader(line string, flags int, prefix string) string {
+	if flags&log.Lmsgprefix == 0 {
+		line = strings.TrimPrefix(line, prefix)
+	}
+	if flags&log.Ldate != 0 && len(line) >= len("2006/01/02 ") && line[4] == '/' && line[7] == '/' {
+		line = line[len("2006/01/02 "):]
+	}
+	if flags&(log.Ltime|log.Lmicroseconds) != 0 && len(line) >= len("15:04:05") && line[2] == ':' && line[5] == ':' {
+		line = line[len("15:04:05"):]
+		if flags&log.Lmicroseconds != 0 && len(line) >= len(".000000") && line[0] == '.' {
+			line = line[len(".000000"):]
+		}
+		line = strings.TrimPrefix(line, " ")
+	}
+	if flags&(log.Lshortfile|log.Llongfile) != 0 {
+		if loc := stdLogFileRe.FindStringIndex(line); loc != nil {
+			line = line[loc[1]:]
+		}
+	}
+	if flags&log.Lmsgprefix != 0 {
+		line = strings.TrimPrefix(line, prefix)
+	}
+	return line
+}
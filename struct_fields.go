@@ -0,0 +1,363 @@
+package logger
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxStructDepth bounds how far nested structs are expanded, so values that
+// point back at themselves still terminate.
+const maxStructDepth = 8
+
+var (
+	structInfoCache sync.Map
+
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+type structField struct {
+	name string
+
+	index []int
+
+	omitEmpty bool
+
+	quoted bool
+
+	nested bool
+
+	depth int
+
+	tagged bool
+
+	redact *redaction
+}
+
+type structInfo struct {
+	fields []structField
+}
+
+// eachStructField calls fn for every loggable field of field, which may be a
+// struct or a pointer to one. Nested structs are flattened into dotted keys,
+// "user.id", for the line based formatters.
+func eachStructField(field interface{}, fn func(key string, value interface{})) {
+	field, fn, r := unwrapRedactedStruct(field, fn)
+	if v, ok := structValue(reflect.ValueOf(field)); ok {
+		walkStruct(v, "", 0, true, r, fn)
+	}
+}
+
+// eachNestedStructField is eachStructField for the JSON based formatters,
+// which get nested structs as nested Fields instead of dotted keys.
+func eachNestedStructField(field interface{}, fn func(key string, value interface{})) {
+	field, fn, r := unwrapRedactedStruct(field, fn)
+	if v, ok := structValue(reflect.ValueOf(field)); ok {
+		walkStruct(v, "", 0, false, r, fn)
+	}
+}
+
+// unwrapRedactedStruct removes the redactedStruct and scrubbedStruct
+// wrappers, passing fn's values through them. It also returns the innermost
+// Redactor, whose Mask and KeepLast apply to the struct's own tag directives.
+func unwrapRedactedStruct(field interface{}, fn func(key string, value interface{})) (interface{}, func(key string, value interface{}), *Redactor) {
+	switch w := field.(type) {
+	case redactedStruct:
+		inner, innerFn, r := unwrapRedactedStruct(w.field, func(key string, value interface{}) {
+			if value, ok := w.redactor.redactField(key, value); ok {
+				fn(key, value)
+			}
+		})
+		if r == nil {
+			r = w.redactor
+		}
+		return inner, innerFn, r
+	case scrubbedStruct:
+		return unwrapRedactedStruct(w.field, func(key string, value interface{}) {
+			if scrubbed, ok := w.scrubber.scrubValue(value); ok {
+				value = scrubbed
+			}
+			fn(key, value)
+		})
+	}
+	return field, fn, nil
+}
+
+func isStructField(field interface{}) bool {
+	_, ok := structValue(reflect.ValueOf(field))
+	return ok
+}
+
+func structValue(v reflect.Value) (reflect.Value, bool) {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	return v, v.Kind() == reflect.Struct
+}
+
+func walkStruct(v reflect.Value, prefix string, depth int, flatten bool, r *Redactor, fn func(key string, value interface{})) {
+	for _, f := range cachedStructInfo(v.Type()).fields {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok || f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		key := prefix + f.name
+		if f.redact != nil {
+			if value, ok := r.applyTag(*f.redact, fv.Interface()); ok {
+				fn(key, value)
+			}
+			continue
+		}
+		if f.nested && depth < maxStructDepth {
+			if nv, ok := structValue(fv); ok {
+				if flatten {
+					walkStruct(nv, key+".", depth+1, flatten, r, fn)
+				} else {
+					nested := Fields{}
+					walkStruct(nv, "", depth+1, flatten, r, func(k string, value interface{}) {
+						nested[k] = value
+					})
+					fn(key, nested)
+				}
+				continue
+			}
+		}
+		if f.quoted {
+			fn(key, quotedValue(fv))
+			continue
+		}
+		fn(key, fv.Interface())
+	}
+}
+
+// fieldByIndex follows index through embedded structs, reporting false when
+// it passes through a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return v, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func quotedValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v.Interface())
+	}
+	return v.Interface()
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Ptr:
+		return v.IsZero()
+	}
+	return false
+}
+
+func cachedStructInfo(t reflect.Type) *structInfo {
+	if info, ok := structInfoCache.Load(t); ok {
+		return info.(*structInfo)
+	}
+	info, _ := structInfoCache.LoadOrStore(t, buildStructInfo(t))
+	return info.(*structInfo)
+}
+
+// buildStructInfo lists the fields of t that carry a log or json tag, with
+// the fields of untagged embedded structs promoted the way encoding/json
+// promotes them: the shallowest field wins, then a tagged one, and ties are
+// dropped.
+func buildStructInfo(t reflect.Type) *structInfo {
+	var fields []structField
+	visited := map[reflect.Type]bool{t: true}
+
+	type pending struct {
+		typ   reflect.Type
+		index []int
+	}
+	current := []pending{{typ: t}}
+	for depth := 0; len(current) > 0; depth++ {
+		var next []pending
+		for _, p := range current {
+			for i := 0; i < p.typ.NumField(); i++ {
+				sf := p.typ.Field(i)
+				index := append(p.index[:len(p.index):len(p.index)], i)
+
+				tag, tagged := parseFieldTag(sf)
+				if tag.redact != nil && tag.redact.strategy == RedactDrop {
+					continue
+				}
+				if sf.Anonymous && !tagged {
+					ft := sf.Type
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if ft.Kind() == reflect.Struct && !visited[ft] {
+						visited[ft] = true
+						next = append(next, pending{typ: ft, index: index})
+					}
+					continue
+				}
+				if !sf.IsExported() || !tagged {
+					continue
+				}
+				name := tag.name
+				if name == "" {
+					name = sf.Name
+				}
+				fields = append(fields, structField{
+					name:      name,
+					index:     index,
+					omitEmpty: tag.omitEmpty,
+					quoted:    tag.quoted,
+					nested:    nestable(sf.Type),
+					depth:     depth,
+					tagged:    tag.name != "",
+					redact:    tag.redact,
+				})
+			}
+		}
+		current = next
+	}
+
+	return &structInfo{fields: dominantFields(fields)}
+}
+
+func dominantFields(fields []structField) []structField {
+	byName := make(map[string][]int, len(fields))
+	for i, f := range fields {
+		byName[f.name] = append(byName[f.name], i)
+	}
+	out := fields[:0:0]
+	for i, f := range fields {
+		candidates := byName[f.name]
+		if len(candidates) == 1 {
+			out = append(out, f)
+			continue
+		}
+		best, ties := -1, 0
+		for _, j := range candidates {
+			switch {
+			case best < 0 || fields[j].depth < fields[best].depth ||
+				fields[j].depth == fields[best].depth && fields[j].tagged && !fields[best].tagged:
+				best, ties = j, 0
+			case fields[j].depth == fields[best].depth && fields[j].tagged == fields[best].tagged:
+				ties++
+			}
+		}
+		if best == i && ties == 0 {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+type fieldTag struct {
+	name string
+
+	omitEmpty bool
+
+	quoted bool
+
+	redact *redaction
+}
+
+// parseFieldTag reads the log tag of sf, falling back to its json tag. A log
+// tag holding only directives, log:"secret" or log:",mask=last4", keeps the
+// name and options of the json tag. "-" is read as the drop directive.
+func parseFieldTag(sf reflect.StructField) (fieldTag, bool) {
+	var tag fieldTag
+	jsonTag, hasJSON := sf.Tag.Lookup("json")
+	logTag, hasLog := sf.Tag.Lookup("log")
+	if hasJSON {
+		tag = parseTagOptions(jsonTag, false)
+	}
+	if hasLog {
+		logOpts := parseTagOptions(logTag, true)
+		if logOpts.name != "" || logOpts.redact != nil && logOpts.redact.strategy == RedactDrop {
+			tag = logOpts
+		} else {
+			tag.omitEmpty = tag.omitEmpty || logOpts.omitEmpty
+			tag.quoted = tag.quoted || logOpts.quoted
+			tag.redact = logOpts.redact
+		}
+	}
+	return tag, hasJSON || hasLog
+}
+
+func parseTagOptions(tag string, directives bool) fieldTag {
+	var parsed fieldTag
+	if tag == "-" {
+		parsed.redact = &redaction{strategy: RedactDrop}
+		return parsed
+	}
+	for i, opt := range strings.Split(tag, ",") {
+		switch opt {
+		case "omitempty":
+			parsed.omitEmpty = true
+			continue
+		case "string":
+			parsed.quoted = true
+			continue
+		}
+		if directives {
+			if red, ok := parseRedaction(opt); ok {
+				parsed.redact = &red
+				continue
+			}
+		}
+		if i == 0 {
+			parsed.name = opt
+		}
+	}
+	return parsed
+}
+
+// nestable reports whether values of t are expanded field by field rather
+// than logged whole. Types that know how to marshal themselves, time.Time
+// among them, are kept as they are.
+func nestable(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	pt := reflect.PointerTo(t)
+	for _, iface := range []reflect.Type{jsonMarshalerType, textMarshalerType, errorType} {
+		if t.Implements(iface) || pt.Implements(iface) {
+			return false
+		}
+	}
+	return true
+}
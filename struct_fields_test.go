@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type tagAddress struct {
+	City string `log:"city"`
+	Zip  string `log:"zip,omitempty"`
+}
+
+type tagBase struct {
+	ID   int    `log:"id"`
+	Kind string `log:"kind"`
+}
+
+type tagDeep struct {
+	Kind string `log:"kind"`
+}
+
+// tagOther's kind is promoted from deeper than tagBase's, so it loses.
+type tagOther struct {
+	tagDeep
+}
+
+type tagUser struct {
+	tagBase
+	*tagOther
+
+	Name     string      `json:"name"`
+	Email    string      `json:"email" log:",mask=last4"`
+	Password string      `log:"password,secret"`
+	Token    string      `json:"token" log:"-"`
+	Note     string      `log:"note,omitempty"`
+	Count    int         `log:"count,string"`
+	Home     tagAddress  `log:"home"`
+	Work     *tagAddress `log:"work"`
+	Created  time.Time   `log:"created"`
+	Untagged string
+	private  string `log:"private"`
+}
+
+func collectStructFields(each func(interface{}, func(string, interface{})), field interface{}) Fields {
+	got := Fields{}
+	each(field, func(key string, value interface{}) {
+		got[key] = value
+	})
+	return got
+}
+
+func TestStructFieldTags(t *testing.T) {
+	created := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	user := &tagUser{
+		tagBase:  tagBase{ID: 7, Kind: "admin"},
+		tagOther: &tagOther{tagDeep{Kind: "other"}},
+		Name:     "bob",
+		Email:    "bob@example.com",
+		Password: "hunter2",
+		Token:    "abc",
+		Count:    3,
+		Home:     tagAddress{City: "Oslo"},
+		Created:  created,
+		Untagged: "x",
+		private:  "y",
+	}
+
+	got := collectStructFields(eachStructField, user)
+	want := Fields{
+		"id":        7,
+		"kind":      "admin",
+		"name":      "bob",
+		"email":     "***********.com",
+		"password":  "[REDACTED]",
+		"count":     "3",
+		"home.city": "Oslo",
+		"work":      (*tagAddress)(nil),
+		"created":   created,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("flattened:\ngot  %v\nwant %v", got, want)
+	}
+
+	got = collectStructFields(eachNestedStructField, user)
+	want["home"] = Fields{"city": "Oslo"}
+	delete(want, "home.city")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nested:\ngot  %v\nwant %v", got, want)
+	}
+}
+
+func TestStructFieldAmbiguousEmbedded(t *testing.T) {
+	// Two untagged embedded structs with a field of the same name at the
+	// same depth cancel out, as they do in encoding/json.
+	type a struct {
+		Name string `log:"name"`
+	}
+	type b struct {
+		Name string `log:"name"`
+	}
+	type both struct {
+		a
+		b
+		ID int `log:"id"`
+	}
+	got := collectStructFields(eachStructField, both{a{"x"}, b{"y"}, 1})
+	if want := (Fields{"id": 1}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestStructFieldNilEmbeddedPointer(t *testing.T) {
+	type inner struct {
+		Deep string `log:"deep"`
+	}
+	type outer struct {
+		*inner
+		ID int `log:"id"`
+	}
+	got := collectStructFields(eachStructField, outer{ID: 1})
+	if want := (Fields{"id": 1}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestStructFieldCycle(t *testing.T) {
+	type node struct {
+		Name string `log:"name"`
+		Next *node  `log:"next"`
+	}
+	n := &node{Name: "a"}
+	n.Next = n
+	got := collectStructFields(eachStructField, n)
+	if len(got) != maxStructDepth+2 {
+		t.Errorf("got %d fields, want %d: %v", len(got), maxStructDepth+2, got)
+	}
+}
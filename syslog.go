@@ -0,0 +1,290 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SyslogFormat uint8
+
+const (
+	RFC5424 SyslogFormat = iota
+	RFC3164
+)
+
+type SyslogFacility int
+
+const (
+	FacilityKern SyslogFacility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLpr
+	FacilityNews
+	FacilityUucp
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFtp
+	_
+	_
+	_
+	_
+	FacilityLocal0
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+const (
+	severityEmergency = iota
+	severityAlert
+	severityCritical
+	severityError
+	severityWarning
+	severityNotice
+	severityInfo
+	severityDebug
+)
+
+const (
+	defaultSyslogStructuredDataID = "fields@32473"
+	rfc5424TimestampFormat        = "2006-01-02T15:04:05.000000Z07:00"
+	rfc3164TimestampFormat        = time.Stamp
+)
+
+func syslogSeverity(level Level) int {
+	switch level {
+	case PanicLevel:
+		return severityEmergency
+	case FatalLevel:
+		return severityCritical
+	case ErrorLevel:
+		return severityError
+	case WarnLevel:
+		return severityWarning
+	case InfoLevel:
+		return severityInfo
+	default:
+		return severityDebug
+	}
+}
+
+type SyslogSink struct {
+	Network string
+
+	Addr string
+
+	TLSConfig *tls.Config
+
+	Format SyslogFormat
+
+	Facility SyslogFacility
+
+	Hostname string
+
+	AppName string
+
+	ProcID string
+
+	MsgID string
+
+	StructuredDataID string
+
+	Level Level
+
+	BufferSize int
+
+	SpoolDir string
+
+	mu sync.Mutex
+
+	writer *NetWriter
+}
+
+func NewSyslogSink(network string, addr string) *SyslogSink {
+	hostname, _ := os.Hostname()
+	return &SyslogSink{
+		Network:          network,
+		Addr:             addr,
+		Facility:         FacilityUser,
+		Hostname:         hostname,
+		AppName:          filepath.Base(os.Args[0]),
+		ProcID:           strconv.Itoa(os.Getpid()),
+		StructuredDataID: defaultSyslogStructuredDataID,
+		Level:            InfoLevel,
+		BufferSize:       defaultNetBufferSize,
+	}
+}
+
+func (s *SyslogSink) Enabled(level Level) bool {
+	return s.Level >= level
+}
+
+// WriteEntry hands the framed message to a NetWriter, which keeps it
+// buffered while the server is unreachable and replays it in order.
+func (s *SyslogSink) WriteEntry(entry *Entry) error {
+	var msg []byte
+	if s.Format == RFC3164 {
+		msg = s.formatRFC3164(entry)
+	} else {
+		msg = s.formatRFC5424(entry)
+	}
+
+	s.mu.Lock()
+	if s.writer == nil {
+		s.writer = NewNetWriter(s.Network, s.Addr)
+		s.writer.TLSConfig = s.TLSConfig
+		s.writer.BufferSize = s.BufferSize
+		s.writer.SpoolDir = s.SpoolDir
+	}
+	writer := s.writer
+	s.mu.Unlock()
+
+	_, err := writer.Write(s.frame(msg))
+	return err
+}
+
+func (s *SyslogSink) Flush() error {
+	s.mu.Lock()
+	writer := s.writer
+	s.mu.Unlock()
+
+	if writer == nil {
+		return nil
+	}
+	return writer.Flush()
+}
+
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	writer := s.writer
+	s.mu.Unlock()
+
+	if writer == nil {
+		return nil
+	}
+	return writer.Close()
+}
+
+// frame applies octet-counting (RFC 6587) on stream transports; datagram
+// transports carry exactly one message per packet.
+func (s *SyslogSink) frame(msg []byte) []byte {
+	switch s.Network {
+	case "tcp", "tcp4", "tcp6", "tls", "unix":
+		return append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	default:
+		return msg
+	}
+}
+
+func (s *SyslogSink) priority(level Level) int {
+	return int(s.Facility)*8 + syslogSeverity(level)
+}
+
+func (s *SyslogSink) formatRFC5424(entry *Entry) []byte {
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "<%d>1 %s %s %s %s %s ",
+		s.priority(entry.Level),
+		entry.Time.Format(rfc5424TimestampFormat),
+		syslogHeaderField(s.Hostname, 255),
+		syslogHeaderField(s.AppName, 48),
+		syslogHeaderField(s.ProcID, 128),
+		syslogHeaderField(s.MsgID, 32))
+
+	if len(entry.Data) == 0 {
+		b.WriteByte('-')
+	} else {
+		b.WriteByte('[')
+		b.WriteString(s.StructuredDataID)
+		for _, k := range sortedKeys(entry.Data) {
+			b.WriteByte(' ')
+			b.WriteString(syslogParamName(k))
+			b.WriteString(`="`)
+			writeSyslogParamValue(b, syslogValue(entry.Data[k]))
+			b.WriteByte('"')
+		}
+		b.WriteByte(']')
+	}
+	if entry.Message != "" {
+		b.WriteByte(' ')
+		b.WriteString(entry.Message)
+	}
+	return b.Bytes()
+}
+
+func (s *SyslogSink) formatRFC3164(entry *Entry) []byte {
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "<%d>%s %s %s[%s]: %s",
+		s.priority(entry.Level),
+		entry.Time.Format(rfc3164TimestampFormat),
+		syslogHeaderField(s.Hostname, 255),
+		syslogHeaderField(s.AppName, 48),
+		syslogHeaderField(s.ProcID, 128),
+		entry.Message)
+	for _, k := range sortedKeys(entry.Data) {
+		fmt.Fprintf(b, " %s=%s", k, strconv.Quote(syslogValue(entry.Data[k])))
+	}
+	return b.Bytes()
+}
+
+func syslogValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func syslogHeaderField(s string, max int) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, s)
+	if s == "" {
+		return "-"
+	}
+	if len(s) > max {
+		s = s[:max]
+	}
+	return s
+}
+
+func syslogParamName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return name
+}
+
+func writeSyslogParamValue(b *bytes.Buffer, value string) {
+	for _, r := range value {
+		if r == '"' || r == '\\' || r == ']' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+}
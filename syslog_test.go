@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSyslogTestEntry() *Entry {
+	entry := NewEntry(New())
+	entry.Time = time.Date(2024, 3, 1, 12, 30, 45, 0, time.UTC)
+	entry.Level = WarnLevel
+	entry.Message = "disk almost full"
+	entry.Data = Fields{"disk": "/var", "used": 97}
+	return entry
+}
+
+func TestSyslogSinkUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sink := NewSyslogSink("udp", conn.LocalAddr().String())
+	sink.Facility = FacilityLocal0
+	sink.Hostname = "host"
+	sink.AppName = "app"
+	sink.ProcID = "42"
+	sink.MsgID = "DISK"
+	defer sink.Close()
+	if err := sink.WriteEntry(newSyslogTestEntry()); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<132>1 2024-03-01T12:30:45.000000Z host app 42 DISK [fields@32473 disk="/var" used="97"] disk almost full`
+	if got := string(buf[:n]); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestSyslogSinkTCPFraming(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	sink := NewSyslogSink("tcp", ln.Addr().String())
+	sink.Format = RFC3164
+	sink.Hostname = "host"
+	sink.AppName = "my app"
+	sink.ProcID = "42"
+	defer sink.Close()
+	for i := 0; i < 2; i++ {
+		if err := sink.WriteEntry(newSyslogTestEntry()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	want := `<12>Mar  1 12:30:45 host myapp[42]: disk almost full disk="/var" used="97"`
+	for i := 0; i < 2; i++ {
+		size, err := r.ReadString(' ')
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(size, " "))
+		if err != nil {
+			t.Fatalf("bad frame length %q", size)
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			t.Fatal(err)
+		}
+		if string(msg) != want {
+			t.Errorf("got  %q\nwant %q", msg, want)
+		}
+	}
+}
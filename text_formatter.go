@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"fmt"
 	"os"
-	"reflect"
 	"runtime"
 	"sort"
 	"strconv"
@@ -60,6 +59,8 @@ type TextFormatter struct {
 
 	CallerPrettyfier func(*runtime.Frame) (function string, file string)
 
+	StructuredErrors bool
+
 	terminalInitOnce sync.Once
 
 	levelTextMaxLength int
@@ -106,7 +107,7 @@ func (f *TextFormatter) Format(entry *Entry) ([]byte, error) {
 	var funcVal, fileVal string
 
 	fixedKeys := make([]string, 0, 4+len(data))
-	if !f.DisableTimestamp {
+	if !f.DisableTimestamp && !entry.omitTime {
 		fixedKeys = append(fixedKeys, f.FieldMap.resolve(FieldKeyTime))
 	}
 	fixedKeys = append(fixedKeys, f.FieldMap.resolve(FieldKeyLevel))
@@ -164,8 +165,18 @@ func (f *TextFormatter) Format(entry *Entry) ([]byte, error) {
 	if f.isColored() {
 		f.printColored(b, entry, keys, data, timestampFormat)
 	} else {
-		m := map[string]interface{}{}
+		structFields := Fields{}
+		structKeys := []string{}
+		eachStructField(entry.Field, func(key string, value interface{}) {
+			if _, ok := structFields[key]; !ok {
+				structKeys = append(structKeys, key)
+			}
+			structFields[key] = value
+		})
 		for _, key := range fixedKeys {
+			if _, ok := structFields[key]; ok {
+				continue
+			}
 			var value interface{}
 			switch {
 			case key == f.FieldMap.resolve(FieldKeyTime):
@@ -183,20 +194,17 @@ func (f *TextFormatter) Format(entry *Entry) ([]byte, error) {
 			default:
 				value = data[key]
 			}
-			m[key] = value
+			f.appendKeyValue(b, key, value)
 		}
-		if nil != entry.Field {
-			_type := reflect.TypeOf(entry.Field)
-			_value := reflect.ValueOf(entry.Field)
-			for k := 0; k < _type.NumField(); k++ {
-				key := _type.Field(k).Tag.Get("json")
-				if len(key) != 0 {
-					m[key] = _value.Field(k).Interface()
-				}
-			}
+		for _, key := range structKeys {
+			f.appendKeyValue(b, key, structFields[key])
 		}
-		for k, v := range m {
-			f.appendKeyValue(b, k, v)
+	}
+	if f.StructuredErrors {
+		for _, key := range sortedKeys(data) {
+			if err, ok := data[key].(error); ok {
+				writeErrorChain(b, key, err)
+			}
 		}
 	}
 
@@ -205,17 +213,7 @@ func (f *TextFormatter) Format(entry *Entry) ([]byte, error) {
 }
 
 func (f *TextFormatter) printColored(b *bytes.Buffer, entry *Entry, keys []string, data Fields, timestampFormat string) {
-	var levelColor int
-	switch entry.Level {
-	case DebugLevel, TraceLevel:
-		levelColor = gray
-	case WarnLevel:
-		levelColor = yellow
-	case ErrorLevel, FatalLevel, PanicLevel:
-		levelColor = red
-	default:
-		levelColor = blue
-	}
+	levelColor := levelColor(entry.Level)
 
 	levelText := strings.ToUpper(entry.Level.String())
 	if !f.DisableLevelTruncation && !f.PadLevelText {
@@ -247,7 +245,7 @@ func (f *TextFormatter) printColored(b *bytes.Buffer, entry *Entry, keys []strin
 	}
 
 	switch {
-	case f.DisableTimestamp:
+	case f.DisableTimestamp || entry.omitTime:
 		fmt.Fprintf(b, "\x1b[%dm%s\x1b[0m%s %-44s ", levelColor, levelText, caller, entry.Message)
 	case !f.FullTimestamp:
 		fmt.Fprintf(b, "\x1b[%dm%s\x1b[0m[%04d]%s %-44s ", levelColor, levelText, int(entry.Time.Sub(baseTimestamp)/time.Second), caller, entry.Message)
@@ -261,6 +259,19 @@ func (f *TextFormatter) printColored(b *bytes.Buffer, entry *Entry, keys []strin
 	}
 }
 
+func levelColor(level Level) int {
+	switch level {
+	case DebugLevel, TraceLevel:
+		return gray
+	case WarnLevel:
+		return yellow
+	case ErrorLevel, FatalLevel, PanicLevel:
+		return red
+	default:
+		return blue
+	}
+}
+
 func (f *TextFormatter) needsQuoting(text string) bool {
 	if f.ForceQuote {
 		return true
@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+)
+
+const (
+	traceparentHeader = "traceparent"
+	tracestateHeader  = "tracestate"
+
+	TraceFlagsSampled byte = 0x01
+)
+
+var errInvalidTraceparent = errors.New("invalid traceparent")
+
+type traceContextKey struct{}
+
+// TraceContext is the W3C Trace Context carried by the traceparent and
+// tracestate headers.
+type TraceContext struct {
+	TraceID [16]byte
+
+	SpanID [8]byte
+
+	Flags byte
+
+	State string
+}
+
+// ParseTraceparent reads a traceparent header, such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01". Headers from
+// later versions are accepted as long as they start with the version 00
+// fields.
+func ParseTraceparent(header string) (TraceContext, error) {
+	var tc TraceContext
+	const size = len("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if len(header) < size || header[2] != '-' || header[35] != '-' || header[52] != '-' {
+		return tc, errInvalidTraceparent
+	}
+	version, ok := decodeTraceHex(header[0:2])
+	if !ok || version[0] == 0xff || version[0] == 0 && len(header) != size ||
+		version[0] != 0 && len(header) > size && header[size] != '-' {
+		return tc, errInvalidTraceparent
+	}
+	traceID, ok1 := decodeTraceHex(header[3:35])
+	spanID, ok2 := decodeTraceHex(header[36:52])
+	flags, ok3 := decodeTraceHex(header[53:55])
+	if !ok1 || !ok2 || !ok3 {
+		return tc, errInvalidTraceparent
+	}
+	copy(tc.TraceID[:], traceID)
+	copy(tc.SpanID[:], spanID)
+	tc.Flags = flags[0]
+	if !tc.IsValid() {
+		return TraceContext{}, errInvalidTraceparent
+	}
+	return tc, nil
+}
+
+// decodeTraceHex decodes lowercase hex, the only case traceparent allows.
+func decodeTraceHex(s string) ([]byte, bool) {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return nil, false
+		}
+	}
+	b, err := hex.DecodeString(s)
+	return b, err == nil
+}
+
+func (tc TraceContext) IsValid() bool {
+	return tc.TraceID != [16]byte{} && tc.SpanID != [8]byte{}
+}
+
+func (tc TraceContext) TraceIDString() string {
+	return hex.EncodeToString(tc.TraceID[:])
+}
+
+func (tc TraceContext) SpanIDString() string {
+	return hex.EncodeToString(tc.SpanID[:])
+}
+
+// String formats tc as a traceparent header.
+func (tc TraceContext) String() string {
+	return "00-" + tc.TraceIDString() + "-" + tc.SpanIDString() + "-" + hex.EncodeToString([]byte{tc.Flags})
+}
+
+// NewTraceContext starts a new trace with random IDs.
+func NewTraceContext() TraceContext {
+	var tc TraceContext
+	rand.Read(tc.TraceID[:])
+	rand.Read(tc.SpanID[:])
+	return tc
+}
+
+// Child returns the context for a new span in the same trace.
+func (tc TraceContext) Child() TraceContext {
+	child := tc
+	rand.Read(child.SpanID[:])
+	return child
+}
+
+func ContextWithTrace(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok && tc.IsValid()
+}
+
+// TraceContextExtractor adds the trace ID, span ID and trace flags of the
+// entry's context as fields. The key names can be changed with fieldMap,
+// using FieldKeyTraceID, FieldKeySpanID and FieldKeyTraceFlags.
+func TraceContextExtractor(fieldMap FieldMap) ContextExtractor {
+	return func(ctx context.Context) Fields {
+		tc, ok := TraceFromContext(ctx)
+		if !ok {
+			return nil
+		}
+		return Fields{
+			fieldMap.resolve(FieldKeyTraceID):    tc.TraceIDString(),
+			fieldMap.resolve(FieldKeySpanID):     tc.SpanIDString(),
+			fieldMap.resolve(FieldKeyTraceFlags): hex.EncodeToString([]byte{tc.Flags}),
+		}
+	}
+}
+
+// TraceMiddleware puts the trace context of each request in its context,
+// continuing the caller's trace from the traceparent header with a new span,
+// or starting a new trace when there is no valid header.
+func TraceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tc, err := ParseTraceparent(r.Header.Get(traceparentHeader))
+		if err != nil {
+			tc = NewTraceContext()
+		} else {
+			tc = tc.Child()
+			tc.State = r.Header.Get(tracestateHeader)
+		}
+		next.ServeHTTP(w, r.WithContext(ContextWithTrace(r.Context(), tc)))
+	})
+}
+
+// TraceTransport sets the traceparent and tracestate headers of outgoing
+// requests from the trace context of their context.
+type TraceTransport struct {
+	Base http.RoundTripper
+}
+
+func (t *TraceTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	tc, ok := TraceFromContext(r.Context())
+	if !ok {
+		return base.RoundTrip(r)
+	}
+	// A RoundTripper must not modify the request it is given.
+	r = r.Clone(r.Context())
+	r.Header.Set(traceparentHeader, tc.String())
+	if tc.State != "" {
+		r.Header.Set(tracestateHeader, tc.State)
+	} else {
+		r.Header.Del(tracestateHeader)
+	}
+	return base.RoundTrip(r)
+}
@@ -6,18 +6,23 @@ import (
 	"runtime"
 )
 
+// Deprecated: every line is logged at the same level. Use NewStdLogWriter,
+// which finds the level of each line, instead.
 func (logger *Logger) Writer() *io.PipeWriter {
 	return logger.WriterLevel(InfoLevel)
 }
 
+// Deprecated: use StdLogWriter.
 func (logger *Logger) WriterLevel(level Level) *io.PipeWriter {
 	return NewEntry(logger).WriterLevel(level)
 }
 
+// Deprecated: use StdLogWriter.
 func (entry *Entry) Writer() *io.PipeWriter {
 	return entry.WriterLevel(InfoLevel)
 }
 
+// Deprecated: use StdLogWriter.
 func (entry *Entry) WriterLevel(level Level) *io.PipeWriter {
 	reader, writer := io.Pipe()
 